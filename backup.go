@@ -0,0 +1,147 @@
+package main
+
+import (
+	"NoFish/repository"
+	"encoding/json"
+	"fmt"
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/storage"
+	"io"
+	"time"
+)
+
+// writeBackup 导出全部数据为 JSON
+func (app *Config) writeBackup(w io.Writer, now time.Time) error {
+	b, err := repository.NewBackup(app.DB, now)
+	if err != nil {
+		return err
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(b)
+}
+
+// readBackup 读取导出的 JSON，检查版本和 id
+func readBackup(r io.Reader) (*repository.Backup, error) {
+	var b repository.Backup
+	if err := json.NewDecoder(r).Decode(&b); err != nil {
+		return nil, fmt.Errorf("%w: %v", repository.ErrInvalidBackup, err)
+	}
+	if err := b.Validate(); err != nil {
+		return nil, err
+	}
+	return &b, nil
+}
+
+// importBackup 导入数据后重新加载规则并刷新界面
+func (app *Config) importBackup(b *repository.Backup, mode string) error {
+	if err := app.DB.Import(b, mode); err != nil {
+		return err
+	}
+
+	// 替换后原来的当前任务和专注记录都已清除
+	if mode == repository.ImportReplace {
+		app.setActiveTask(0)
+		app.Focus.Restore()
+		app.refreshFocus()
+	}
+	app.loadFishRules()
+	if app.Summary != nil {
+		app.refreshSum()
+		app.refreshTasksTable()
+		app.refreshPrizesTable()
+	}
+	if app.StatsCharts != nil {
+		app.refreshStats()
+	}
+	return nil
+}
+
+// dataMenu 菜单里的数据导入导出
+func (app *Config) dataMenu() *fyne.Menu {
+	items := []*fyne.MenuItem{
+		fyne.NewMenuItem("导出全部数据", app.exportBackupDialog),
+		fyne.NewMenuItem("导入并合并", func() {
+			app.importBackupDialog(repository.ImportMerge)
+		}),
+		fyne.NewMenuItem("导入并替换", func() {
+			app.importBackupDialog(repository.ImportReplace)
+		}),
+		fyne.NewMenuItemSeparator(),
+	}
+	return fyne.NewMenu("数据", append(items, app.csvMenuItems()...)...)
+}
+
+// exportBackupDialog 选择保存位置，导出 JSON
+func (app *Config) exportBackupDialog() {
+	save := dialog.NewFileSave(func(writer fyne.URIWriteCloser, err error) {
+		if err != nil {
+			dialog.ShowError(err, app.MainWindow)
+			app.ErrorLog.Println(err)
+			return
+		}
+		if writer == nil {
+			return
+		}
+		defer writer.Close()
+
+		if err := app.writeBackup(writer, time.Now()); err != nil {
+			dialog.ShowError(err, app.MainWindow)
+			app.ErrorLog.Println(err)
+		}
+	}, app.MainWindow)
+	save.SetFileName(fmt.Sprintf("nofish-%s.json", time.Now().Format("2006-01-02")))
+	save.SetFilter(storage.NewExtensionFileFilter([]string{".json"}))
+	save.Show()
+}
+
+// importBackupDialog 选择导出的 JSON 导入，替换前需要确认
+func (app *Config) importBackupDialog(mode string) {
+	open := dialog.NewFileOpen(func(reader fyne.URIReadCloser, err error) {
+		if err != nil {
+			dialog.ShowError(err, app.MainWindow)
+			app.ErrorLog.Println(err)
+			return
+		}
+		if reader == nil {
+			return
+		}
+		defer reader.Close()
+
+		b, err := readBackup(reader)
+		if err != nil {
+			dialog.ShowError(err, app.MainWindow)
+			app.ErrorLog.Println(err)
+			return
+		}
+
+		doImport := func() {
+			if err := app.importBackup(b, mode); err != nil {
+				dialog.ShowError(err, app.MainWindow)
+				app.ErrorLog.Println(err)
+				return
+			}
+			dialog.ShowInformation("导入完成", backupText(b), app.MainWindow)
+		}
+		if mode != repository.ImportReplace {
+			doImport()
+			return
+		}
+		dialog.ShowConfirm("导入并替换",
+			"现有的任务、奖品、积分流水、每日概况、规则和活动记录会被清空，确定导入吗？\n"+backupText(b),
+			func(ok bool) {
+				if ok {
+					doImport()
+				}
+			}, app.MainWindow)
+	}, app.MainWindow)
+	open.SetFilter(storage.NewExtensionFileFilter([]string{".json"}))
+	open.Show()
+}
+
+// backupText 导出文件里各类记录的数量
+func backupText(b *repository.Backup) string {
+	return fmt.Sprintf("导出于 %s：任务 %d 个，奖品 %d 个，积分流水 %d 条，每日概况 %d 天，规则 %d 条，活动记录 %d 条",
+		b.ExportedAt.Format("2006-01-02 15:04"), len(b.Tasks), len(b.Prizes), len(b.Ledger), len(b.Summaries), len(b.Rules), len(b.Activity))
+}
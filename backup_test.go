@@ -0,0 +1,52 @@
+package main
+
+import (
+	"NoFish/repository"
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestApp_writeBackup(t *testing.T) {
+	now := time.Date(2023, 7, 3, 20, 0, 0, 0, time.Local)
+	var buf bytes.Buffer
+	if err := testApp.writeBackup(&buf, now); err != nil {
+		t.Fatal("export failed:", err)
+	}
+
+	b, err := readBackup(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatal("read backup failed:", err)
+	}
+	rules, _ := testApp.DB.AllRules()
+	if b.Version != repository.BackupVersion || !b.ExportedAt.Equal(now) || len(b.Rules) != len(rules) {
+		t.Error("wrong backup:", b.Version, b.ExportedAt, len(b.Rules))
+	}
+
+	// 合并导入刚导出的数据不会产生重复记录
+	tasks, _ := testApp.DB.AllTasks()
+	balance, _ := testApp.DB.PointsBalance()
+	if err = testApp.importBackup(b, repository.ImportMerge); err != nil {
+		t.Fatal("merge failed:", err)
+	}
+	afterTasks, _ := testApp.DB.AllTasks()
+	afterRules, _ := testApp.DB.AllRules()
+	afterBalance, _ := testApp.DB.PointsBalance()
+	if len(afterTasks) != len(tasks) || len(afterRules) != len(rules) || afterBalance != balance {
+		t.Error("merging own export duplicated data:", len(afterTasks), len(afterRules), afterBalance)
+	}
+}
+
+func TestReadBackup(t *testing.T) {
+	if _, err := readBackup(strings.NewReader("not json")); !errors.Is(err, repository.ErrInvalidBackup) {
+		t.Error("expected ErrInvalidBackup for broken file, got", err)
+	}
+	if _, err := readBackup(strings.NewReader(`{"version": 99}`)); !errors.Is(err, repository.ErrBackupVersion) {
+		t.Error("expected ErrBackupVersion for newer file, got", err)
+	}
+	if _, err := readBackup(strings.NewReader(`{"version": 1, "prizes": [{"id": 1}, {"id": 1}]}`)); !errors.Is(err, repository.ErrInvalidBackup) {
+		t.Error("expected ErrInvalidBackup for duplicated ids, got", err)
+	}
+}
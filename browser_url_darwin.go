@@ -0,0 +1,27 @@
+package main
+
+import (
+	"os/exec"
+	"strings"
+)
+
+// browserScripts 各浏览器获取当前标签页网址的 AppleScript
+var browserScripts = map[string]string{
+	"google chrome":  `tell application "Google Chrome" to get URL of active tab of front window`,
+	"microsoft edge": `tell application "Microsoft Edge" to get URL of active tab of front window`,
+	"brave browser":  `tell application "Brave Browser" to get URL of active tab of front window`,
+	"safari":         `tell application "Safari" to get URL of front document`,
+}
+
+// activeBrowserURL 通过 AppleScript 读取浏览器当前网址，不是浏览器或读取失败返回空
+func activeBrowserURL(app string) string {
+	script, ok := browserScripts[strings.ToLower(app)]
+	if !ok {
+		return ""
+	}
+	out, err := exec.Command("osascript", "-e", script).Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
@@ -0,0 +1,8 @@
+//go:build !darwin
+
+package main
+
+// activeBrowserURL 只有 mac 上能读取浏览器网址
+func activeBrowserURL(app string) string {
+	return ""
+}
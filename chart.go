@@ -0,0 +1,94 @@
+package main
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+)
+
+// 图表配色
+var (
+	chartBackground = color.RGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff}
+	chartAxis       = color.RGBA{R: 0x90, G: 0x90, B: 0x90, A: 0xff}
+	chartGrid       = color.RGBA{R: 0xe6, G: 0xe6, B: 0xe6, A: 0xff}
+
+	colorProductive  = color.RGBA{R: 0x4c, G: 0xaf, B: 0x50, A: 0xff}
+	colorDistracting = color.RGBA{R: 0xe5, G: 0x39, B: 0x35, A: 0xff}
+	colorTasks       = color.RGBA{R: 0x1e, G: 0x88, B: 0xe5, A: 0xff}
+	colorEarned      = color.RGBA{R: 0xfb, G: 0xc0, B: 0x2d, A: 0xff}
+	colorSpent       = color.RGBA{R: 0x8e, G: 0x24, B: 0xaa, A: 0xff}
+)
+
+// 图表四周留白，单位像素
+const chartPadding = 8
+
+// ChartSeries 一组数据，和 BarChart.Labels 一一对应
+type ChartSeries struct {
+	Name   string
+	Color  color.RGBA
+	Values []int
+}
+
+// BarChart 分组柱状图，每个标签一组，每组每个系列一根柱子
+type BarChart struct {
+	Labels []string
+	Series []ChartSeries
+}
+
+// Max 所有系列的最大值，用于坐标轴刻度
+func (c BarChart) Max() int {
+	max := 0
+	for _, s := range c.Series {
+		for _, v := range s.Values {
+			if v > max {
+				max = v
+			}
+		}
+	}
+	return max
+}
+
+// Render 画成 width x height 的图片，文字由界面另外显示
+func (c BarChart) Render(width, height int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	fillRect(img, img.Bounds(), chartBackground)
+
+	plot := image.Rect(chartPadding, chartPadding, width-chartPadding, height-chartPadding)
+	// 4 条横向网格线
+	for i := 1; i <= 4; i++ {
+		y := plot.Max.Y - plot.Dy()*i/4
+		fillRect(img, image.Rect(plot.Min.X, y, plot.Max.X, y+1), chartGrid)
+	}
+
+	max := c.Max()
+	groups := len(c.Labels)
+	if groups > 0 && len(c.Series) > 0 && max > 0 {
+		groupWidth := plot.Dx() / groups
+		// 每组两边各留 1/6 的空隙
+		gap := groupWidth / 6
+		barWidth := (groupWidth - 2*gap) / len(c.Series)
+		if barWidth < 1 {
+			barWidth = 1
+		}
+		for g := 0; g < groups; g++ {
+			x := plot.Min.X + g*groupWidth + gap
+			for _, s := range c.Series {
+				if g < len(s.Values) && s.Values[g] > 0 {
+					h := plot.Dy() * s.Values[g] / max
+					fillRect(img, image.Rect(x, plot.Max.Y-h, x+barWidth, plot.Max.Y), s.Color)
+				}
+				x += barWidth
+			}
+		}
+	}
+
+	// 坐标轴
+	fillRect(img, image.Rect(plot.Min.X, plot.Min.Y, plot.Min.X+1, plot.Max.Y), chartAxis)
+	fillRect(img, image.Rect(plot.Min.X, plot.Max.Y, plot.Max.X, plot.Max.Y+1), chartAxis)
+
+	return img
+}
+
+func fillRect(img draw.Image, r image.Rectangle, c color.Color) {
+	draw.Draw(img, r, &image.Uniform{C: c}, image.Point{}, draw.Src)
+}
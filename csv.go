@@ -0,0 +1,506 @@
+package main
+
+import (
+	"NoFish/repository"
+	"bufio"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/storage"
+	"fyne.io/fyne/v2/widget"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 表格软件需要 BOM 才能认出 UTF-8 的中文
+const utf8BOM = "\ufeff"
+
+// csvColumn CSV 的一列，导出时用 name 作表头，导入时 name 和 aliases 都能识别
+type csvColumn struct {
+	name     string
+	aliases  []string
+	required bool
+}
+
+var taskColumns = []csvColumn{
+	{name: "名字", aliases: []string{"name", "任务名", "任务"}, required: true},
+	{name: "描述", aliases: []string{"description", "desc"}},
+	{name: "截止日期", aliases: []string{"due_date", "deadline", "截止时间"}, required: true},
+	{name: "积分", aliases: []string{"points", "score"}},
+	{name: "预估用时", aliases: []string{"estimate_minutes", "estimate", "预估"}},
+	{name: "类型", aliases: []string{"type", "is_long_term"}},
+	{name: "优先级", aliases: []string{"priority"}},
+	{name: "完成", aliases: []string{"completed"}},
+	{name: "完成时间", aliases: []string{"completed_at"}},
+}
+
+var prizeColumns = []csvColumn{
+	{name: "描述", aliases: []string{"description", "desc", "奖品"}, required: true},
+	{name: "积分", aliases: []string{"points", "score"}, required: true},
+	{name: "是否重复", aliases: []string{"is_repeat", "repeat", "重复"}},
+}
+
+// CSVRowError 某一行导入失败的原因，Row 是文件里的行号，表头为第 1 行
+type CSVRowError struct {
+	Row int
+	Err error
+}
+
+func (e CSVRowError) Error() string {
+	return fmt.Sprintf("第 %d 行：%v", e.Row, e.Err)
+}
+
+// 优先级和任务类型在数据库里的取值，和新增任务对话框一致
+var (
+	priorityTexts = map[int]string{1: "高", 2: "中", 3: "低"}
+	taskTypeTexts = map[int]string{1: "长期", 2: "短期"}
+)
+
+// priorityText 优先级的文字，未知的优先级为空
+func priorityText(priority int) string {
+	return priorityTexts[priority]
+}
+
+// parsePriority 高/中/低 转为优先级，不填为中
+func parsePriority(text string) (int, error) {
+	return parseChoice(text, priorityTexts, 2, "优先级只能是 高/中/低")
+}
+
+// parseTaskType 长期/短期 转为任务类型，不填为短期
+func parseTaskType(text string) (int, error) {
+	return parseChoice(text, taskTypeTexts, 2, "类型只能是 长期/短期")
+}
+
+// parseYesNo 是/否 转为 1/0，不填为否
+func parseYesNo(text string) (int, error) {
+	return parseChoice(text, map[int]string{1: "是", 0: "否"}, 0, "只能填 是/否")
+}
+
+func parseChoice(text string, choices map[int]string, empty int, message string) (int, error) {
+	if text == "" {
+		return empty, nil
+	}
+	for value, choice := range choices {
+		if text == choice {
+			return value, nil
+		}
+	}
+	return 0, fmt.Errorf("%s，不能是 %q", message, text)
+}
+
+func yesNoText(b bool) string {
+	if b {
+		return "是"
+	}
+	return "否"
+}
+
+// csvHeader 根据表头找到每一列的位置，没有的列为 -1，缺少必填列时报错
+func csvHeader(header []string, columns []csvColumn) ([]int, error) {
+	index := make([]int, len(columns))
+	for i, c := range columns {
+		index[i] = -1
+		for j, h := range header {
+			h = strings.ToLower(strings.TrimSpace(h))
+			if h == strings.ToLower(c.name) || containsString(c.aliases, h) {
+				index[i] = j
+				break
+			}
+		}
+		if index[i] < 0 && c.required {
+			return nil, fmt.Errorf("缺少 %s 列", c.name)
+		}
+	}
+	return index, nil
+}
+
+func containsString(all []string, s string) bool {
+	for _, x := range all {
+		if strings.ToLower(x) == s {
+			return true
+		}
+	}
+	return false
+}
+
+// readCSV 读取表头和每一行，parse 返回的错误按行记录，不影响其他行
+func readCSV(r io.Reader, columns []csvColumn, parse func(fields []string) error) ([]CSVRowError, error) {
+	// 去掉表格软件写入的 BOM
+	buffered := bufio.NewReader(r)
+	if bom, err := buffered.Peek(len(utf8BOM)); err == nil && string(bom) == utf8BOM {
+		_, _ = buffered.Discard(len(utf8BOM))
+	}
+	reader := csv.NewReader(buffered)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, errors.New("文件是空的")
+	}
+	if err != nil {
+		return nil, err
+	}
+	index, err := csvHeader(header, columns)
+	if err != nil {
+		return nil, err
+	}
+
+	var rowErrs []CSVRowError
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		// 带换行的字段会占多行，行号取这一条记录开始的行
+		row, _ := reader.FieldPos(0)
+		if strings.TrimSpace(strings.Join(record, "")) == "" {
+			continue
+		}
+
+		fields := make([]string, len(columns))
+		for i, j := range index {
+			if j >= 0 && j < len(record) {
+				fields[i] = strings.TrimSpace(record[j])
+			}
+		}
+		if err := parse(fields); err != nil {
+			rowErrs = append(rowErrs, CSVRowError{Row: row, Err: err})
+		}
+	}
+	return rowErrs, nil
+}
+
+// writeCSV 写入 BOM、表头和每一行
+func writeCSV(w io.Writer, columns []csvColumn, rows [][]string) error {
+	if _, err := io.WriteString(w, utf8BOM); err != nil {
+		return err
+	}
+	writer := csv.NewWriter(w)
+	if err := writer.Write(columnNames(columns)); err != nil {
+		return err
+	}
+	if err := writer.WriteAll(rows); err != nil {
+		return err
+	}
+	return writer.Error()
+}
+
+func columnNames(columns []csvColumn) []string {
+	var names []string
+	for _, c := range columns {
+		names = append(names, c.name)
+	}
+	return names
+}
+
+// taskRecord 任务对应 taskColumns 的一行
+func taskRecord(t repository.Task) []string {
+	estimate, completedAt := "", ""
+	if t.EstimateMinutes > 0 {
+		estimate = strconv.Itoa(t.EstimateMinutes)
+	}
+	if !t.CompletedAt.IsZero() {
+		completedAt = t.CompletedAt.Format("2006-01-02 15:04")
+	}
+	return []string{
+		t.Name,
+		t.Description,
+		t.DueDate.Format("2006-01-02"),
+		strconv.Itoa(t.Points),
+		estimate,
+		taskTypeTexts[t.IsLongTerm],
+		priorityText(t.Priority),
+		yesNoText(t.Completed),
+		completedAt,
+	}
+}
+
+// parseTaskRecord 按 taskColumns 的顺序解析一行任务
+func parseTaskRecord(fields []string) (repository.Task, error) {
+	var t repository.Task
+	var err error
+
+	if t.Name = fields[0]; t.Name == "" {
+		return t, errors.New("名字不能为空")
+	}
+	t.Description = fields[1]
+	if t.DueDate, err = time.ParseInLocation("2006-01-02", fields[2], time.Local); err != nil {
+		return t, fmt.Errorf("截止日期应为 YYYY-MM-DD，不能是 %q", fields[2])
+	}
+	if fields[3] != "" {
+		if t.Points, err = strconv.Atoi(fields[3]); err != nil {
+			return t, fmt.Errorf("积分应为整数，不能是 %q", fields[3])
+		}
+	}
+	if fields[4] != "" {
+		if t.EstimateMinutes, err = strconv.Atoi(fields[4]); err != nil || t.EstimateMinutes < 0 {
+			return t, fmt.Errorf("预估用时应为分钟数，不能是 %q", fields[4])
+		}
+	}
+	if t.IsLongTerm, err = parseTaskType(fields[5]); err != nil {
+		return t, err
+	}
+	if t.Priority, err = parsePriority(fields[6]); err != nil {
+		return t, err
+	}
+	completed, err := parseYesNo(fields[7])
+	if err != nil {
+		return t, fmt.Errorf("完成%w", err)
+	}
+	t.Completed = completed == 1
+	if fields[8] != "" {
+		if t.CompletedAt, err = time.ParseInLocation("2006-01-02 15:04", fields[8], time.Local); err != nil {
+			return t, fmt.Errorf("完成时间应为 YYYY-MM-DD HH:MM，不能是 %q", fields[8])
+		}
+		t.Completed = true
+	} else if t.Completed {
+		t.CompletedAt = t.DueDate
+	}
+	return t, nil
+}
+
+// writeTasksCSV 导出任务
+func writeTasksCSV(w io.Writer, tasks []repository.Task) error {
+	var rows [][]string
+	for _, t := range tasks {
+		rows = append(rows, taskRecord(t))
+	}
+	return writeCSV(w, taskColumns, rows)
+}
+
+// readTasksCSV 读取任务，有错误的行不返回，原因记在 CSVRowError 里
+func readTasksCSV(r io.Reader) ([]repository.Task, []CSVRowError, error) {
+	var tasks []repository.Task
+	rowErrs, err := readCSV(r, taskColumns, func(fields []string) error {
+		t, err := parseTaskRecord(fields)
+		if err == nil {
+			tasks = append(tasks, t)
+		}
+		return err
+	})
+	return tasks, rowErrs, err
+}
+
+// prizeRecord 奖品对应 prizeColumns 的一行
+func prizeRecord(p repository.Prize) []string {
+	return []string{p.Description, strconv.Itoa(p.Points), yesNoText(p.IsRepeat == 1)}
+}
+
+// parsePrizeRecord 按 prizeColumns 的顺序解析一行奖品
+func parsePrizeRecord(fields []string) (repository.Prize, error) {
+	var p repository.Prize
+	var err error
+
+	if p.Description = fields[0]; p.Description == "" {
+		return p, errors.New("描述不能为空")
+	}
+	if p.Points, err = strconv.Atoi(fields[1]); err != nil || p.Points < 0 {
+		return p, fmt.Errorf("积分应为非负整数，不能是 %q", fields[1])
+	}
+	if p.IsRepeat, err = parseYesNo(fields[2]); err != nil {
+		return p, fmt.Errorf("是否重复%w", err)
+	}
+	return p, nil
+}
+
+// writePrizesCSV 导出奖品
+func writePrizesCSV(w io.Writer, prizes []repository.Prize) error {
+	var rows [][]string
+	for _, p := range prizes {
+		rows = append(rows, prizeRecord(p))
+	}
+	return writeCSV(w, prizeColumns, rows)
+}
+
+// readPrizesCSV 读取奖品，有错误的行不返回，原因记在 CSVRowError 里
+func readPrizesCSV(r io.Reader) ([]repository.Prize, []CSVRowError, error) {
+	var prizes []repository.Prize
+	rowErrs, err := readCSV(r, prizeColumns, func(fields []string) error {
+		p, err := parsePrizeRecord(fields)
+		if err == nil {
+			prizes = append(prizes, p)
+		}
+		return err
+	})
+	return prizes, rowErrs, err
+}
+
+// importTasks 写入预览过的任务，全部成功或全部不写入
+func (app *Config) importTasks(tasks []repository.Task) error {
+	if _, err := app.DB.InsertTasks(tasks); err != nil {
+		return err
+	}
+	if app.TasksTable != nil {
+		app.refreshTasksTable()
+	}
+	return nil
+}
+
+// importPrizes 写入预览过的奖品，全部成功或全部不写入
+func (app *Config) importPrizes(prizes []repository.Prize) error {
+	if _, err := app.DB.InsertPrizes(prizes); err != nil {
+		return err
+	}
+	if app.PrizesTable != nil {
+		app.refreshPrizesTable()
+	}
+	return nil
+}
+
+// csvMenuItems 数据菜单里的 CSV 导入导出
+func (app *Config) csvMenuItems() []*fyne.MenuItem {
+	return []*fyne.MenuItem{
+		fyne.NewMenuItem("导出任务 CSV", func() {
+			app.exportCSVDialog("tasks.csv", func(w io.Writer) error {
+				tasks, err := app.DB.AllTasks()
+				if err != nil {
+					return err
+				}
+				return writeTasksCSV(w, tasks)
+			})
+		}),
+		fyne.NewMenuItem("导入任务 CSV", func() {
+			app.importCSVDialog(func(r io.Reader) error {
+				tasks, rowErrs, err := readTasksCSV(r)
+				if err != nil {
+					return err
+				}
+				var rows [][]string
+				for _, t := range tasks {
+					rows = append(rows, taskRecord(t))
+				}
+				app.csvPreviewDialog("导入任务", columnNames(taskColumns), rows, rowErrs, func() error {
+					return app.importTasks(tasks)
+				})
+				return nil
+			})
+		}),
+		fyne.NewMenuItem("导出奖品 CSV", func() {
+			app.exportCSVDialog("prizes.csv", func(w io.Writer) error {
+				prizes, err := app.DB.AllPrizes()
+				if err != nil {
+					return err
+				}
+				return writePrizesCSV(w, prizes)
+			})
+		}),
+		fyne.NewMenuItem("导入奖品 CSV", func() {
+			app.importCSVDialog(func(r io.Reader) error {
+				prizes, rowErrs, err := readPrizesCSV(r)
+				if err != nil {
+					return err
+				}
+				var rows [][]string
+				for _, p := range prizes {
+					rows = append(rows, prizeRecord(p))
+				}
+				app.csvPreviewDialog("导入奖品", columnNames(prizeColumns), rows, rowErrs, func() error {
+					return app.importPrizes(prizes)
+				})
+				return nil
+			})
+		}),
+	}
+}
+
+// exportCSVDialog 选择保存位置，用 write 写入 CSV
+func (app *Config) exportCSVDialog(fileName string, write func(w io.Writer) error) {
+	save := dialog.NewFileSave(func(writer fyne.URIWriteCloser, err error) {
+		if err != nil {
+			dialog.ShowError(err, app.MainWindow)
+			app.ErrorLog.Println(err)
+			return
+		}
+		if writer == nil {
+			return
+		}
+		defer writer.Close()
+
+		if err := write(writer); err != nil {
+			dialog.ShowError(err, app.MainWindow)
+			app.ErrorLog.Println(err)
+		}
+	}, app.MainWindow)
+	save.SetFileName(fileName)
+	save.SetFilter(storage.NewExtensionFileFilter([]string{".csv"}))
+	save.Show()
+}
+
+// importCSVDialog 选择 CSV 文件，用 read 读取并预览
+func (app *Config) importCSVDialog(read func(r io.Reader) error) {
+	open := dialog.NewFileOpen(func(reader fyne.URIReadCloser, err error) {
+		if err != nil {
+			dialog.ShowError(err, app.MainWindow)
+			app.ErrorLog.Println(err)
+			return
+		}
+		if reader == nil {
+			return
+		}
+		defer reader.Close()
+
+		if err := read(reader); err != nil {
+			dialog.ShowError(err, app.MainWindow)
+			app.ErrorLog.Println(err)
+		}
+	}, app.MainWindow)
+	open.SetFilter(storage.NewExtensionFileFilter([]string{".csv"}))
+	open.Show()
+}
+
+// csvPreviewDialog 预览可以导入的行和出错的行，确认后只导入没有错误的行
+func (app *Config) csvPreviewDialog(title string, header []string, rows [][]string, rowErrs []CSVRowError, commit func() error) {
+	table := widget.NewTable(
+		func() (int, int) {
+			return len(rows) + 1, len(header)
+		},
+		func() fyne.CanvasObject {
+			return widget.NewLabel("")
+		},
+		func(i widget.TableCellID, o fyne.CanvasObject) {
+			if i.Row == 0 {
+				o.(*widget.Label).SetText(header[i.Col])
+				return
+			}
+			o.(*widget.Label).SetText(rows[i.Row-1][i.Col])
+		},
+	)
+	for col := range header {
+		table.SetColumnWidth(col, 100)
+	}
+
+	summary := widget.NewLabel(fmt.Sprintf("可以导入 %d 行，%d 行有错误", len(rows), len(rowErrs)))
+	var messages []string
+	for _, e := range rowErrs {
+		messages = append(messages, e.Error())
+	}
+	errorsText := widget.NewLabel(strings.Join(messages, "\n"))
+	errorsText.Wrapping = fyne.TextWrapWord
+
+	content := container.NewBorder(summary, nil, nil, nil,
+		container.NewVSplit(table, container.NewVScroll(errorsText)))
+	if len(rowErrs) == 0 {
+		content = container.NewBorder(summary, nil, nil, nil, table)
+	}
+
+	confirm := dialog.NewCustomConfirm(title, "导入", "取消", content, func(ok bool) {
+		if !ok {
+			return
+		}
+		if err := commit(); err != nil {
+			dialog.ShowError(err, app.MainWindow)
+			app.ErrorLog.Println(err)
+		}
+	}, app.MainWindow)
+	confirm.Resize(fyne.NewSize(760, 480))
+	confirm.Show()
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"NoFish/repository"
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTasksCSV_RoundTrip(t *testing.T) {
+	due := time.Date(2023, 8, 1, 0, 0, 0, 0, time.Local)
+	tasks := []repository.Task{
+		{Name: "写方案, 第二版", Description: "包含\"引号\"\n和换行", DueDate: due, Points: 5, EstimateMinutes: 90, IsLongTerm: 1, Priority: 1},
+		{Name: "交周报", DueDate: due, Points: 1, IsLongTerm: 2, Priority: 3, Completed: true, CompletedAt: due.Add(17*time.Hour + 30*time.Minute)},
+	}
+
+	var buf bytes.Buffer
+	if err := writeTasksCSV(&buf, tasks); err != nil {
+		t.Fatal("write csv failed:", err)
+	}
+	if !strings.HasPrefix(buf.String(), utf8BOM+"名字,描述,截止日期,积分,预估用时,类型,优先级,完成,完成时间\n") {
+		t.Error("wrong header:", buf.String())
+	}
+	if !strings.Contains(buf.String(), ",90,长期,高,否,") {
+		t.Error("type and priority not written as text:", buf.String())
+	}
+
+	got, rowErrs, err := readTasksCSV(&buf)
+	if err != nil || len(rowErrs) != 0 || len(got) != 2 {
+		t.Fatal("read csv failed:", got, rowErrs, err)
+	}
+	for i := range tasks {
+		want := tasks[i]
+		if got[i].Name != want.Name || got[i].Description != want.Description || !got[i].DueDate.Equal(want.DueDate) ||
+			got[i].Points != want.Points || got[i].EstimateMinutes != want.EstimateMinutes || got[i].IsLongTerm != want.IsLongTerm ||
+			got[i].Priority != want.Priority || got[i].Completed != want.Completed || !got[i].CompletedAt.Equal(want.CompletedAt) {
+			t.Errorf("task %d not preserved: got %+v, want %+v", i, got[i], want)
+		}
+	}
+}
+
+func TestReadTasksCSV(t *testing.T) {
+	// 表头可以用英文别名、顺序随意，多余的列忽略
+	input := "Priority,name,due_date,备注,type\n" +
+		"低,买咖啡豆,2023-08-02,\"随便\n写写\",\n" +
+		"紧急,写代码,2023-08-02,,短期\n" +
+		"高,,2023-08-02,,\n" +
+		",看论文,2023/08/02,,\n" +
+		",,,,\n" +
+		"中,整理笔记,2023-08-03,,长期\n"
+
+	tasks, rowErrs, err := readTasksCSV(strings.NewReader(input))
+	if err != nil {
+		t.Fatal("read csv failed:", err)
+	}
+	if len(tasks) != 2 || tasks[0].Name != "买咖啡豆" || tasks[0].Priority != 3 || tasks[0].IsLongTerm != 2 || tasks[1].IsLongTerm != 1 {
+		t.Error("wrong tasks:", tasks)
+	}
+	// 空行跳过，行号按文件里的行算，字段里的换行也算一行
+	if len(rowErrs) != 3 || rowErrs[0].Row != 4 || rowErrs[1].Row != 5 || rowErrs[2].Row != 6 {
+		t.Fatal("wrong row errors:", rowErrs)
+	}
+	if !strings.Contains(rowErrs[0].Error(), "第 4 行：优先级只能是 高/中/低") {
+		t.Error("wrong error message:", rowErrs[0].Error())
+	}
+
+	if _, _, err = readTasksCSV(strings.NewReader("name,description\n写代码,\n")); err == nil {
+		t.Error("expected error for missing due date column")
+	}
+	if _, _, err = readTasksCSV(strings.NewReader("")); err == nil {
+		t.Error("expected error for empty file")
+	}
+}
+
+func TestPrizesCSV(t *testing.T) {
+	var buf bytes.Buffer
+	if err := writePrizesCSV(&buf, []repository.Prize{{Description: "奶茶", Points: 10, IsRepeat: 1}, {Description: "新键盘", Points: 300}}); err != nil {
+		t.Fatal("write csv failed:", err)
+	}
+	prizes, rowErrs, err := readPrizesCSV(&buf)
+	if err != nil || len(rowErrs) != 0 || len(prizes) != 2 || prizes[0].IsRepeat != 1 || prizes[1].IsRepeat != 0 || prizes[1].Points != 300 {
+		t.Fatal("prizes not preserved:", prizes, rowErrs, err)
+	}
+
+	prizes, rowErrs, _ = readPrizesCSV(strings.NewReader("描述,积分,是否重复\n看电影,50,偶尔\n睡懒觉,-1,是\n"))
+	if len(prizes) != 0 || len(rowErrs) != 2 {
+		t.Error("expected invalid prizes reported:", prizes, rowErrs)
+	}
+}
+
+func TestApp_importPrizes(t *testing.T) {
+	before, _ := testApp.DB.AllPrizes()
+	if err := testApp.importPrizes([]repository.Prize{{Description: "CSV 奖品", Points: 7}}); err != nil {
+		t.Fatal("import prizes failed:", err)
+	}
+	after, _ := testApp.DB.AllPrizes()
+	if len(after) != len(before)+1 || after[len(after)-1].Description != "CSV 奖品" {
+		t.Fatal("prize not imported:", after)
+	}
+	_ = testApp.DB.DeletePrize(after[len(after)-1].ID)
+}
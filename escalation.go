@@ -0,0 +1,118 @@
+package main
+
+import (
+	"NoFish/repository"
+	"fmt"
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+	"time"
+)
+
+// 摸鱼升级处理：当天摸鱼次数达到设定值后依次加重，0 表示不启用该级
+// 第几次摸鱼开始弹出需要确认的对话框
+var escalateModalAt = 3
+
+// 第几次摸鱼开始扣分，每次扣 escalateDeductPoints 分
+var escalateDeductAt = 5
+var escalateDeductPoints = 1
+
+// 第几次摸鱼开始全屏警告
+var escalateFullScreenAt = 0
+
+// Escalation 一次摸鱼要采取的处理
+type Escalation struct {
+	Notify     bool
+	Modal      bool
+	FullScreen bool
+	// 扣除的积分，不扣为 0
+	Deduct int
+}
+
+// escalationFor 当天第 count 次摸鱼的处理，达到的级别都会执行，全屏警告代替弹窗
+func escalationFor(count int) Escalation {
+	rulesLock.RLock()
+	defer rulesLock.RUnlock()
+
+	reached := func(at int) bool {
+		return at > 0 && count >= at
+	}
+
+	e := Escalation{Notify: notifyFish}
+	if reached(escalateDeductAt) {
+		e.Deduct = escalateDeductPoints
+	}
+	if reached(escalateFullScreenAt) {
+		e.FullScreen = true
+	} else if reached(escalateModalAt) {
+		e.Modal = true
+	}
+	return e
+}
+
+// escalateFish 按当天第 count 次摸鱼发出警告、扣分，wait 为连续摸鱼的时长
+func (app *Config) escalateFish(count int, wait time.Duration) Escalation {
+	e := escalationFor(count)
+
+	content := fmt.Sprintf("你已经摸鱼%s了,今日共摸鱼%d次", durationText(wait), count)
+	if e.Deduct > 0 {
+		content += fmt.Sprintf("，扣除%d积分", e.Deduct)
+	}
+
+	var n repository.Notification
+	if e.Notify {
+		n = app.notify(repository.NotificationFish, "摸鱼警告", content)
+	}
+
+	if e.Deduct > 0 {
+		_, err := app.DB.InsertLedgerEntry(repository.LedgerEntry{
+			Kind:      repository.LedgerPenalty,
+			Points:    -e.Deduct,
+			CreatedAt: time.Now(),
+			Note:      fmt.Sprintf("今日第%d次摸鱼", count),
+		})
+		if err != nil {
+			app.ErrorLog.Println(err)
+		}
+		if app.Summary != nil {
+			app.refreshSum()
+		}
+	}
+
+	if e.FullScreen {
+		app.showFullScreenNag(content, n.ID)
+	} else if e.Modal {
+		app.showFishModal(content, n.ID)
+	}
+
+	return e
+}
+
+// showFishModal 在主窗口弹出摸鱼警告，需要点确认才能关闭
+func (app *Config) showFishModal(content string, notificationID int64) {
+	d := dialog.NewCustom("摸鱼警告", "我知道了，回去工作", widget.NewLabel(content), app.MainWindow)
+	d.SetOnClosed(func() {
+		app.dismissNotification(notificationID)
+	})
+	d.Show()
+}
+
+// showFullScreenNag 全屏警告，点按钮后关闭
+func (app *Config) showFullScreenNag(content string, notificationID int64) {
+	w := app.App.NewWindow("摸鱼警告")
+
+	text := canvas.NewText(content, nil)
+	text.TextSize = 32
+	text.Alignment = fyne.TextAlignCenter
+	back := widget.NewButton("我知道了，回去工作", func() {
+		app.dismissNotification(notificationID)
+		w.Close()
+	})
+	back.Importance = widget.HighImportance
+
+	w.SetContent(container.NewCenter(container.NewVBox(text, back)))
+	w.SetFullScreen(true)
+	w.Show()
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"NoFish/repository"
+	"testing"
+	"time"
+)
+
+// restoreBalance 测试结束后把扣掉的积分加回来
+func restoreBalance(t *testing.T) {
+	before, _ := testApp.DB.PointsBalance()
+	t.Cleanup(func() {
+		after, _ := testApp.DB.PointsBalance()
+		if after != before {
+			_, _ = testApp.DB.InsertLedgerEntry(repository.LedgerEntry{
+				Kind:      repository.LedgerAdjusted,
+				Points:    before - after,
+				CreatedAt: time.Now(),
+			})
+		}
+	})
+}
+
+func TestEscalationFor(t *testing.T) {
+	restoreBalance(t)
+	_ = testApp.DB.SaveSetting(settingEscalateModalAt, "2")
+	_ = testApp.DB.SaveSetting(settingEscalateDeductAt, "3")
+	_ = testApp.DB.SaveSetting(settingEscalateDeductPoints, "2")
+	_ = testApp.DB.SaveSetting(settingEscalateFullScreenAt, "4")
+	testApp.loadFishRules()
+	defer func() {
+		_ = testApp.DB.SaveSetting(settingEscalateModalAt, "3")
+		_ = testApp.DB.SaveSetting(settingEscalateDeductAt, "5")
+		_ = testApp.DB.SaveSetting(settingEscalateDeductPoints, "1")
+		_ = testApp.DB.SaveSetting(settingEscalateFullScreenAt, "0")
+		testApp.loadFishRules()
+	}()
+
+	cases := []struct {
+		count    int
+		expected Escalation
+	}{
+		{1, Escalation{Notify: true}},
+		{2, Escalation{Notify: true, Modal: true}},
+		{3, Escalation{Notify: true, Modal: true, Deduct: 2}},
+		{4, Escalation{Notify: true, FullScreen: true, Deduct: 2}},
+	}
+	for _, c := range cases {
+		if e := escalationFor(c.count); e != c.expected {
+			t.Errorf("count %d: expected %+v, got %+v", c.count, c.expected, e)
+		}
+	}
+
+	// 扣分记入积分流水
+	before, _ := testApp.DB.PointsBalance()
+	testApp.escalateFish(3, 5*time.Minute)
+	after, _ := testApp.DB.PointsBalance()
+	if after != before-2 {
+		t.Errorf("expected 2 points deducted, balance went from %d to %d", before, after)
+	}
+	entries, _ := testApp.DB.AllLedgerEntries()
+	last := entries[len(entries)-1]
+	if last.Kind != repository.LedgerPenalty || last.Points != -2 {
+		t.Error("wrong penalty entry:", last)
+	}
+}
+
+func TestFishChecker_EscalationResetsDaily(t *testing.T) {
+	restoreBalance(t)
+	_ = testApp.DB.SaveSetting(settingWaitTime, "1")
+	_ = testApp.DB.SaveSetting(settingEscalateDeductAt, "2")
+	testApp.loadFishRules()
+	defer func() {
+		_ = testApp.DB.SaveSetting(settingWaitTime, "5")
+		_ = testApp.DB.SaveSetting(settingEscalateDeductAt, "5")
+		testApp.loadFishRules()
+	}()
+
+	fishing := Window{Title: "Google 搜索"}
+	before, _ := testApp.DB.PointsBalance()
+
+	// 第一天摸鱼两次，第二次扣分
+	_, step := newTestChecker(time.Date(2023, 2, 13, 0, 0, 0, 0, time.Local), fishing)
+	step(8)
+	balance, _ := testApp.DB.PointsBalance()
+	if balance != before-1 {
+		t.Fatalf("expected 1 point deducted on second fish event, balance went from %d to %d", before, balance)
+	}
+
+	// 第二天重新计数，第一次不扣分
+	_, step = newTestChecker(time.Date(2023, 2, 14, 0, 0, 0, 0, time.Local), fishing)
+	step(4)
+	after, _ := testApp.DB.PointsBalance()
+	if after != balance {
+		t.Errorf("escalation not reset on a new day, balance went from %d to %d", balance, after)
+	}
+}
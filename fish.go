@@ -1,119 +1,321 @@
 package main
 
 import (
+	"NoFish/repository"
 	"fmt"
-	"fyne.io/fyne/v2"
-	"github.com/go-vgo/robotgo"
 	"log"
-	"strings"
+	"sync"
 	"time"
 )
 
-var fishesMap = map[string]int{}
+// 规则和配置从数据库加载，检查时可能正在重新加载，读写都要加锁
+var rulesLock sync.RWMutex
 
-// 白名单list,后续可以通过页面增加
-var whiteList = []string{"微信读书", "马士兵", "知识星球", "小报童", "xzgedu"}
+// 黑白名单规则,来自数据库 rules 表
+var ruleEngine *RuleEngine
 
-// 黑名单list,后续可以通过页面增加
-var blackList = []string{"google", "知乎", "即刻"}
+// 专注时使用的规则，包括只在专注时生效的规则
+var focusRuleEngine *RuleEngine
 
 // 判断是否摸鱼的等待时间
 var waitTime = 5
 
-// 设定每日开始时间
-var beginTime = 9
+// 工作时间表，来自数据库 work_ranges 和 schedule_exceptions 表
+var workSchedule *Schedule
 
-// 设定每日结束时间
-var endTime = 18
+// 多久没有输入算离开电脑，单位分钟
+var idleThreshold = 3
 
-var lastLearnTime = time.Now()
+// 连续看电脑多久提醒休息，单位分钟
+var restInterval = 20
 
-// takeARest 20分钟提醒休息一下
-func takeARest() {
-	tick := time.Tick(20 * time.Minute)
-	for {
-		<-tick
-		if isInWorkTime() {
-			fyne.CurrentApp().SendNotification(&fyne.Notification{
-				Title:   "休息一下",
-				Content: "看电脑20分钟了，休息一下比较好",
-			})
+// 离开电脑多久算休息过，单位分钟
+var restBreak = 5
+
+// 采样间隔
+var sampleInterval = 20 * time.Second
+
+// 是否发送摸鱼警告和休息提醒
+var notifyFish = true
+var notifyRest = true
+
+// loadFishRules 从数据库加载黑白名单、工作时间表和时间配置，没有配置的项保持原值
+func (app *Config) loadFishRules() {
+	rules, err := app.DB.AllRules()
+	if err != nil {
+		app.ErrorLog.Println(err)
+		return
+	}
+	settings, err := app.DB.AllSettings()
+	if err != nil {
+		app.ErrorLog.Println(err)
+		return
+	}
+	ranges, err := app.DB.AllWorkRanges()
+	if err != nil {
+		app.ErrorLog.Println(err)
+		return
+	}
+	exceptions, err := app.DB.AllScheduleExceptions()
+	if err != nil {
+		app.ErrorLog.Println(err)
+		return
+	}
+
+	// 专注时全部规则都生效，平时跳过只在专注时生效的规则，错误只记录一次
+	focusEngine, errs := NewRuleEngine(rules)
+	for _, err := range errs {
+		app.ErrorLog.Println(err)
+	}
+	var normal []repository.Rule
+	for _, r := range rules {
+		if !r.FocusOnly {
+			normal = append(normal, r)
 		}
 	}
+	engine, _ := NewRuleEngine(normal)
+
+	rulesLock.Lock()
+	defer rulesLock.Unlock()
+	ruleEngine = engine
+	focusRuleEngine = focusEngine
+	waitTime = intSetting(settings, settingWaitTime, waitTime)
+	workSchedule = NewSchedule(ranges, exceptions)
+	idleThreshold = intSetting(settings, settingIdleThreshold, idleThreshold)
+	restInterval = intSetting(settings, settingRestInterval, restInterval)
+	restBreak = intSetting(settings, settingRestBreak, restBreak)
+	sampleInterval = time.Duration(intSetting(settings, settingSampleInterval, int(sampleInterval.Seconds()))) * time.Second
+	notifyFish = boolSetting(settings, settingNotifyFish, notifyFish)
+	notifyRest = boolSetting(settings, settingNotifyRest, notifyRest)
+	pomodoroWork = intSetting(settings, settingPomodoroWork, pomodoroWork)
+	pomodoroShortBreak = intSetting(settings, settingPomodoroShortBreak, pomodoroShortBreak)
+	pomodoroLongBreak = intSetting(settings, settingPomodoroLongBreak, pomodoroLongBreak)
+	pomodoroLongEvery = intSetting(settings, settingPomodoroLongEvery, pomodoroLongEvery)
+	notifyPomodoro = boolSetting(settings, settingNotifyPomodoro, notifyPomodoro)
+	activeTask = int64(intSetting(settings, settingActiveTask, int(activeTask)))
+	escalateModalAt = intSetting(settings, settingEscalateModalAt, escalateModalAt)
+	escalateDeductAt = intSetting(settings, settingEscalateDeductAt, escalateDeductAt)
+	escalateDeductPoints = intSetting(settings, settingEscalateDeductPoints, escalateDeductPoints)
+	escalateFullScreenAt = intSetting(settings, settingEscalateFullScreenAt, escalateFullScreenAt)
+	focusMinutes = intSetting(settings, settingFocusMinutes, focusMinutes)
+	focusWaitSeconds = intSetting(settings, settingFocusWaitSeconds, focusWaitSeconds)
+	focusExitPenalty = intSetting(settings, settingFocusExitPenalty, focusExitPenalty)
+}
+
+// currentSampleInterval 当前的采样间隔，设置修改后下一次采样生效
+func currentSampleInterval() time.Duration {
+	rulesLock.RLock()
+	defer rulesLock.RUnlock()
+
+	return sampleInterval
 }
 
-// fishCheck 摸鱼检查
-func fishCheck() {
-	tick := time.Tick(20 * time.Second)
+// FishChecker 定时采样活动窗口，判断是否在摸鱼
+type FishChecker struct {
+	app   *Config
+	probe WindowProbe
+	idle  IdleProbe
+	// 时钟，测试时可以替换
+	now func() time.Time
+
+	// 连续摸鱼的累计时长，在工作窗口清零，中性窗口暂停
+	fishDuration time.Duration
+	// 上一次采样时间
+	lastSampleTime time.Time
+}
+
+// NewFishChecker 使用 probe 读取窗口、idle 判断是否离开的检查器
+func NewFishChecker(app *Config, probe WindowProbe, idle IdleProbe) *FishChecker {
+	return &FishChecker{
+		app:   app,
+		probe: probe,
+		idle:  idle,
+		now:   time.Now,
+	}
+}
+
+// Run 摸鱼检查
+func (c *FishChecker) Run() {
 	for {
-		if isInWorkTime() {
-			fishCheckTask()
+		// 每次检查前重新加载规则，修改后不用重启
+		c.app.loadFishRules()
+		// 专注时不管是不是工作时间都检查
+		if isInWorkTime(c.now()) || c.app.Focus.Active() {
+			c.Check()
 		}
-		<-tick
-	}
-}
-
-// fishCheckTask 具体摸鱼检查
-func fishCheckTask() {
-
-	title := strings.ToLower(robotgo.GetTitle())
-	// 黑名单内直接判断
-	if isInBlackList(title) {
-		log.Println("当前窗口标题：", title, "，疑似在摸鱼,最近摸鱼时间：", lastLearnTime)
-		// 记录摸鱼时间,如果超过5分钟就弹窗
-		if time.Now().Sub(lastLearnTime) > time.Duration(waitTime)*time.Minute {
-			fmt.Println("摸鱼时间超过5分钟")
-			today := time.Now().Format("2006-01-02")
-			// 获取今日日期
-			fishesMap[today]++
-			fyne.CurrentApp().SendNotification(&fyne.Notification{
-				Title:   "摸鱼警告",
-				Content: "你已经摸鱼5分钟了,今日共摸鱼" + fmt.Sprintf("%d", fishesMap[today]) + "次",
-			})
-			myApp.FishCount = fishesMap[today]
-			lastLearnTime = time.Now()
+		time.Sleep(currentSampleInterval())
+	}
+}
+
+// Check 具体摸鱼检查，采样一次并更新摸鱼计时
+func (c *FishChecker) Check() {
+	now := c.now()
+	elapsed := c.sampleElapsed(now)
+	today := now.Format("2006-01-02")
+
+	sample := c.sample()
+	title := sample.Title
+
+	// 离开电脑时摸鱼计时暂停，也不计入任何分类的时长
+	if isIdle(c.idle) {
+		log.Println("当前窗口：", title, "，已离开电脑，暂停计时")
+		c.app.recordActivity(now.Add(-elapsed), now, sample, repository.CategoryIdle, RuleMatch{})
+		return
+	}
+
+	focusing := c.app.Focus.Active()
+	match := matchRules(sample, focusing)
+	category := classify(match)
+	log.Println("当前窗口：", title, "应用：", sample.App, "网址：", sample.URL, "，", category, "，", match.Explain())
+	c.app.addCategoryTime(today, category, elapsed)
+	c.app.recordActivity(now.Add(-elapsed), now, sample, category, match)
+
+	switch category {
+	case repository.CategoryProductive:
+		// 在工作/学习，清空摸鱼计时，时长记到当前任务上
+		c.fishDuration = 0
+		c.app.addTaskTime(elapsed)
+		log.Println("当前窗口标题：", title, " 在工作，重新开始计时")
+	case repository.CategoryNeutral:
+		// 中性窗口，摸鱼计时暂停
+	case repository.CategoryDistracting:
+		c.fishDuration += elapsed
+		log.Println("当前窗口标题：", title, "，疑似在摸鱼,已连续摸鱼：", c.fishDuration)
+		// 记录摸鱼时间,如果超过等待时间就弹窗，专注时等待时间更短
+		wait := fishThreshold(focusing)
+		if c.fishDuration > wait {
+			fmt.Printf("摸鱼时间超过%s\n", durationText(wait))
+			if focusing {
+				c.app.Focus.RecordFish()
+			}
+			// 写入今日概况，重启后不丢失
+			err := c.app.DB.IncrementSummary(today, 1, 0, 0)
+			if err != nil {
+				c.app.ErrorLog.Println(err)
+			}
+			c.app.loadTodaySummary()
+			// 按今天第几次摸鱼升级处理，第二天重新计数
+			s, err := c.app.DB.GetSummaryByDay(today)
+			if err != nil {
+				c.app.ErrorLog.Println(err)
+			} else {
+				c.app.escalateFish(int(s.FishCount), wait)
+			}
+			c.fishDuration = 0
 		}
-	} else {
-		// 清空最近摸鱼时间
-		lastLearnTime = time.Now()
-		log.Println("当前窗口标题：", title, " 非摸鱼，重新开始计时：", lastLearnTime)
 	}
 }
 
-// isInWorkTime 工作时间检测
-func isInWorkTime() bool {
-	now := time.Now()
-	hour := now.Hour()
-	minute := now.Minute()
-	if hour < beginTime || hour > endTime {
-		return false
+// FishDuration 当前连续摸鱼的时长
+func (c *FishChecker) FishDuration() time.Duration {
+	return c.fishDuration
+}
+
+// sampleElapsed 距上一次采样的时长
+func (c *FishChecker) sampleElapsed(now time.Time) time.Duration {
+	elapsed := elapsedSince(c.lastSampleTime, now)
+	c.lastSampleTime = now
+	return elapsed
+}
+
+// elapsedSince 距上一次采样的时长，第一次采样或中间停过（不在工作时间）按一个采样间隔算
+func elapsedSince(last, now time.Time) time.Duration {
+	interval := currentSampleInterval()
+	elapsed := now.Sub(last)
+	if last.IsZero() || elapsed > 2*interval || elapsed < 0 {
+		elapsed = interval
+	}
+	return elapsed
+}
+
+// sample 采样当前活动窗口
+func (c *FishChecker) sample() Sample {
+	s := Sample{
+		Title: c.probe.ActiveTitle(),
+		App:   c.probe.ActiveApp(),
+	}
+	if p, ok := c.probe.(URLProbe); ok {
+		s.URL = p.ActiveURL(s.App)
+	}
+	return s
+}
+
+// classify 白名单算工作，黑名单算摸鱼，都没命中算中性
+func classify(match RuleMatch) string {
+	if match.Rule == nil {
+		return repository.CategoryNeutral
 	}
-	if hour == beginTime && minute < 30 {
-		return false
+	if match.Rule.Kind == repository.RuleAllow {
+		return repository.CategoryProductive
 	}
-	if hour == endTime && minute > 0 {
-		return false
+	return repository.CategoryDistracting
+}
+
+// recordActivity 记录一次采样到活动日志
+func (app *Config) recordActivity(from, to time.Time, s Sample, category string, match RuleMatch) {
+	a := repository.Activity{
+		StartedAt: from,
+		EndedAt:   to,
+		Title:     s.Title,
+		App:       s.App,
+		URL:       s.URL,
+		Category:  category,
+	}
+	if match.Rule != nil {
+		a.RuleID = match.Rule.ID
+	}
+	_, err := app.DB.RecordActivity(a)
+	if err != nil {
+		app.ErrorLog.Println(err)
 	}
-	return true
+}
+
+// addCategoryTime 累加某天该分类的时长
+func (app *Config) addCategoryTime(day, category string, elapsed time.Duration) {
+	err := app.DB.IncrementSummaryTime(day, category, int(elapsed.Seconds()))
+	if err != nil {
+		app.ErrorLog.Println(err)
+	}
+}
+
+// addTaskTime 累加当前任务的实际用时，没有当前任务时跳过
+func (app *Config) addTaskTime(elapsed time.Duration) {
+	task := currentActiveTask()
+	if task == 0 {
+		return
+	}
+	if err := app.DB.AddTaskTime(task, int(elapsed.Seconds())); err != nil {
+		app.ErrorLog.Println(err)
+	}
+}
+
+// matchRules 用当前加载的规则匹配采样，focus 表示正在专注
+func matchRules(s Sample, focus bool) RuleMatch {
+	rulesLock.RLock()
+	defer rulesLock.RUnlock()
+
+	if focus {
+		return focusRuleEngine.Match(s)
+	}
+	return ruleEngine.Match(s)
+}
+
+// isInWorkTime t 是否在工作时间表内
+func isInWorkTime(t time.Time) bool {
+	rulesLock.RLock()
+	defer rulesLock.RUnlock()
+
+	return workSchedule.Active(t)
 }
 
 // isInWhiteList 白名单检测
 func isInWhiteList(title string) bool {
-	for _, white := range whiteList {
-		if strings.Contains(title, white) {
-			return true
-		}
-	}
-	return false
+	match := matchRules(Sample{Title: title}, false)
+	return match.Rule != nil && match.Rule.Kind == repository.RuleAllow
 }
 
 // isInBlackList 黑名单检测
 func isInBlackList(title string) bool {
-	for _, black := range blackList {
-		if strings.Contains(title, black) {
-			return true
-		}
-	}
-	return false
-
+	match := matchRules(Sample{Title: title}, false)
+	return match.Rule != nil && match.Rule.Kind == repository.RuleBlock
 }
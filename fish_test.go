@@ -0,0 +1,210 @@
+package main
+
+import (
+	"NoFish/repository"
+	"testing"
+	"time"
+)
+
+func TestApp_loadFishRules(t *testing.T) {
+	r, _ := testApp.DB.InsertRule(repository.Rule{Kind: repository.RuleBlock, Pattern: "BiliBili", Enabled: true})
+	_ = testApp.DB.SaveSetting(settingWaitTime, "3")
+	testApp.loadFishRules()
+
+	if !isInBlackList("bilibili - 首页") {
+		t.Error("new block rule not loaded")
+	}
+	if !isInWhiteList("微信读书") {
+		t.Error("default allow rule not loaded")
+	}
+	if waitTime != 3 {
+		t.Errorf("wait time not loaded: expected 3, got %d", waitTime)
+	}
+
+	// 停用后重新加载即生效
+	r.Enabled = false
+	_ = testApp.DB.UpdateRule(r.ID, *r)
+	testApp.loadFishRules()
+	if isInBlackList("bilibili - 首页") {
+		t.Error("disabled rule still applied")
+	}
+
+	_ = testApp.DB.SaveSetting(settingWaitTime, "5")
+	testApp.loadFishRules()
+}
+
+func TestClassify(t *testing.T) {
+	allow := repository.Rule{Kind: repository.RuleAllow}
+	block := repository.Rule{Kind: repository.RuleBlock}
+
+	if c := classify(RuleMatch{Rule: &allow}); c != repository.CategoryProductive {
+		t.Error("allow rule should be productive, got", c)
+	}
+	if c := classify(RuleMatch{Rule: &block}); c != repository.CategoryDistracting {
+		t.Error("block rule should be distracting, got", c)
+	}
+	if c := classify(RuleMatch{}); c != repository.CategoryNeutral {
+		t.Error("no rule should be neutral, got", c)
+	}
+}
+
+// newTestChecker 返回使用假时钟的检查器，每次 Check 后时钟前进一个采样间隔
+func newTestChecker(day time.Time, windows ...Window) (*FishChecker, func(n int)) {
+	clock := day.Add(10 * time.Hour)
+	c := NewFishChecker(&testApp, NewScriptedProbe(windows...), IdleFunc(func() time.Duration { return 0 }))
+	c.now = func() time.Time { return clock }
+
+	step := func(n int) {
+		for i := 0; i < n; i++ {
+			c.Check()
+			clock = clock.Add(sampleInterval)
+		}
+	}
+	return c, step
+}
+
+func TestFishChecker_Threshold(t *testing.T) {
+	_ = testApp.DB.SaveSetting(settingWaitTime, "5")
+	testApp.loadFishRules()
+
+	day := time.Date(2023, 2, 6, 0, 0, 0, 0, time.Local)
+	c, step := newTestChecker(day, Window{Title: "Google 搜索", App: "Safari"})
+
+	// 15 次采样正好 5 分钟，还没超过
+	step(15)
+	if c.FishDuration() != 5*time.Minute {
+		t.Errorf("expected 5m of fishing, got %v", c.FishDuration())
+	}
+	s, _ := testApp.DB.GetSummaryByDay("2023-02-06")
+	if s.FishCount != 0 {
+		t.Error("warned before threshold:", s.FishCount)
+	}
+
+	// 超过 5 分钟记一次摸鱼，计时清零
+	step(1)
+	s, _ = testApp.DB.GetSummaryByDay("2023-02-06")
+	if s.FishCount != 1 {
+		t.Errorf("expected 1 fish event, got %d", s.FishCount)
+	}
+	sent := testNotifier.Notifications()
+	if len(sent) == 0 || sent[len(sent)-1].Kind != repository.NotificationFish {
+		t.Error("fish warning not sent:", sent)
+	}
+	if c.FishDuration() != 0 {
+		t.Error("fish timer not reset after warning:", c.FishDuration())
+	}
+	if s.DistractingSeconds != 16*20 {
+		t.Errorf("expected %d distracting seconds, got %d", 16*20, s.DistractingSeconds)
+	}
+}
+
+func TestFishChecker_ProductiveAndNeutral(t *testing.T) {
+	_ = testApp.DB.SaveSetting(settingWaitTime, "5")
+	testApp.loadFishRules()
+
+	fishing := Window{Title: "Google 搜索"}
+	neutral := Window{Title: "访达"}
+	reading := Window{Title: "微信读书"}
+	day := time.Date(2023, 2, 7, 0, 0, 0, 0, time.Local)
+	c, step := newTestChecker(day,
+		fishing, fishing, fishing,
+		neutral, neutral,
+		fishing,
+		reading,
+	)
+
+	step(5)
+	// 中性窗口暂停计时
+	if c.FishDuration() != 3*sampleInterval {
+		t.Errorf("neutral windows should pause the timer, got %v", c.FishDuration())
+	}
+	step(1)
+	if c.FishDuration() != 4*sampleInterval {
+		t.Errorf("expected timer to resume, got %v", c.FishDuration())
+	}
+	// 工作窗口清零
+	step(1)
+	if c.FishDuration() != 0 {
+		t.Errorf("productive window should reset the timer, got %v", c.FishDuration())
+	}
+
+	s, _ := testApp.DB.GetSummaryByDay("2023-02-07")
+	if s.DistractingSeconds != 80 || s.NeutralSeconds != 40 || s.ProductiveSeconds != 20 {
+		t.Error("wrong category time:", s)
+	}
+
+	// 活动日志里相邻的相同采样被合并
+	activity, _ := testApp.DB.ActivitiesBetween(day, day.Add(24*time.Hour))
+	if len(activity) != 4 || activity[0].Samples != 3 || activity[0].Category != repository.CategoryDistracting {
+		t.Error("wrong activity recorded:", activity)
+	}
+}
+
+func TestFishChecker_GapCountsOneInterval(t *testing.T) {
+	day := time.Date(2023, 2, 8, 0, 0, 0, 0, time.Local)
+	c, _ := newTestChecker(day, Window{Title: "Google 搜索"})
+	clock := day.Add(10 * time.Hour)
+	c.now = func() time.Time { return clock }
+
+	c.Check()
+	// 中间停了一小时（例如午休不在工作时间），只按一个采样间隔计
+	clock = clock.Add(time.Hour)
+	c.Check()
+	if c.FishDuration() != 2*sampleInterval {
+		t.Errorf("expected gap to count as one interval, got %v", c.FishDuration())
+	}
+}
+
+func TestFishChecker_IdlePausesTimer(t *testing.T) {
+	_ = testApp.DB.SaveSetting(settingWaitTime, "5")
+	testApp.loadFishRules()
+
+	day := time.Date(2023, 2, 9, 0, 0, 0, 0, time.Local)
+	c, step := newTestChecker(day, Window{Title: "Google 搜索"})
+	idle := time.Duration(0)
+	c.idle = IdleFunc(func() time.Duration { return idle })
+
+	step(3)
+	// 午饭时浏览器开着，人不在
+	idle = time.Hour
+	step(30)
+	if c.FishDuration() != 3*sampleInterval {
+		t.Errorf("idle time should not count as fishing, got %v", c.FishDuration())
+	}
+
+	s, _ := testApp.DB.GetSummaryByDay("2023-02-09")
+	if s.FishCount != 0 || s.DistractingSeconds != 60 {
+		t.Error("idle samples counted in summary:", s)
+	}
+	activity, _ := testApp.DB.ActivitiesBetween(day, day.Add(24*time.Hour))
+	if len(activity) != 2 || activity[1].Category != repository.CategoryIdle {
+		t.Error("idle samples not marked in activity:", activity)
+	}
+}
+
+func TestFishChecker_ActiveTaskTime(t *testing.T) {
+	task, _ := testApp.DB.InsertTask(repository.Task{Name: "写文档", DueDate: time.Now(), Priority: 2, EstimateMinutes: 30})
+	testApp.setActiveTask(task.ID)
+	defer func() {
+		testApp.setActiveTask(0)
+		_ = testApp.DB.DeleteTask(task.ID)
+	}()
+
+	reading := Window{Title: "微信读书"}
+	fishing := Window{Title: "Google 搜索"}
+	day := time.Date(2023, 2, 10, 0, 0, 0, 0, time.Local)
+	_, step := newTestChecker(day, reading, reading, fishing, reading)
+	step(4)
+
+	// 只有工作窗口的时长记到当前任务上
+	fetched, _ := testApp.DB.GetTaskByID(int(task.ID))
+	if fetched.SpentSeconds != 3*20 {
+		t.Errorf("expected 60 seconds on the active task, got %d", fetched.SpentSeconds)
+	}
+
+	// 重新加载配置后当前任务不变
+	testApp.loadFishRules()
+	if currentActiveTask() != task.ID {
+		t.Error("active task not persisted, got", currentActiveTask())
+	}
+}
@@ -0,0 +1,314 @@
+package main
+
+import (
+	"NoFish/repository"
+	"database/sql"
+	"errors"
+	"fmt"
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// 默认专注时长，单位分钟
+var focusMinutes = 50
+
+// 专注时连续摸鱼多久算摸鱼，单位秒
+var focusWaitSeconds = 30
+
+// 提前结束专注扣除的积分
+var focusExitPenalty = 5
+
+// FocusMode 专注模式：专注期间只在专注时生效的规则也会启用，摸鱼等待时间更短，
+// 提前结束要扣积分，每次专注都记录到数据库
+type FocusMode struct {
+	mu  sync.Mutex
+	app *Config
+	// 时钟，测试时可以替换
+	now func() time.Time
+
+	// 当前专注，没有专注为 nil
+	session *repository.FocusSession
+}
+
+// NewFocusMode 未在专注的专注模式
+func NewFocusMode(app *Config) *FocusMode {
+	return &FocusMode{
+		app: app,
+		now: time.Now,
+	}
+}
+
+// Run 每秒检查一次专注是否结束，并刷新倒计时
+func (f *FocusMode) Run() {
+	for range time.Tick(time.Second) {
+		f.Tick()
+		f.app.refreshFocus()
+	}
+}
+
+// Restore 恢复数据库里还没结束的专注，已经过了计划时长的按完成处理，没有时清掉内存里的专注
+func (f *FocusMode) Restore() {
+	s, err := f.app.DB.ActiveFocusSession()
+	if errors.Is(err, sql.ErrNoRows) {
+		// 数据库里的专注记录被清空时，内存里的专注也一起结束
+		f.mu.Lock()
+		f.session = nil
+		f.mu.Unlock()
+		return
+	}
+	if err != nil {
+		f.app.ErrorLog.Println(err)
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.session = s
+	if end := f.plannedEnd(); !f.now().Before(end) {
+		f.end(repository.FocusCompleted, end)
+	}
+}
+
+// Start 为任务开始专注 minutes 分钟，taskID 为 0 表示不关联任务
+func (f *FocusMode) Start(taskID int64, minutes int) error {
+	if minutes <= 0 {
+		return errors.New("专注时长应大于 0")
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.session != nil {
+		return errors.New("已经在专注了")
+	}
+	s, err := f.app.DB.InsertFocusSession(repository.FocusSession{
+		TaskID:         taskID,
+		StartedAt:      f.now(),
+		PlannedMinutes: minutes,
+		Status:         repository.FocusActive,
+	})
+	if err != nil {
+		return err
+	}
+	f.session = s
+	return nil
+}
+
+// Stop 提前结束专注并扣除积分，返回扣除的积分
+func (f *FocusMode) Stop() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.session == nil {
+		return 0
+	}
+
+	rulesLock.RLock()
+	penalty := focusExitPenalty
+	rulesLock.RUnlock()
+
+	if penalty > 0 {
+		f.session.PenaltyPoints = penalty
+		_, err := f.app.DB.InsertLedgerEntry(repository.LedgerEntry{
+			Kind:      repository.LedgerPenalty,
+			Points:    -penalty,
+			CreatedAt: f.now(),
+			Note:      "提前结束专注",
+		})
+		if err != nil {
+			f.app.ErrorLog.Println(err)
+		}
+		if f.app.Summary != nil {
+			f.app.refreshSum()
+		}
+	}
+	f.end(repository.FocusAbandoned, f.now())
+	return penalty
+}
+
+// Tick 专注满计划时长后结束，返回是否刚刚完成
+func (f *FocusMode) Tick() bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.session == nil {
+		return false
+	}
+	end := f.plannedEnd()
+	if f.now().Before(end) {
+		return false
+	}
+
+	minutes := f.session.PlannedMinutes
+	f.end(repository.FocusCompleted, end)
+	f.app.notify(repository.NotificationFocus, "专注完成", fmt.Sprintf("完成了 %d 分钟的专注", minutes))
+	return true
+}
+
+// Active 是否在专注
+func (f *FocusMode) Active() bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.session != nil
+}
+
+// Session 当前专注和剩余时间，没有专注时 ok 为 false
+func (f *FocusMode) Session() (s repository.FocusSession, remaining time.Duration, ok bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.session == nil {
+		return s, 0, false
+	}
+	return *f.session, f.plannedEnd().Sub(f.now()), true
+}
+
+// RecordFish 专注期间摸鱼一次
+func (f *FocusMode) RecordFish() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.session == nil {
+		return
+	}
+	f.session.FishCount++
+	if err := f.app.DB.UpdateFocusSession(f.session.ID, *f.session); err != nil {
+		f.app.ErrorLog.Println(err)
+	}
+}
+
+func (f *FocusMode) plannedEnd() time.Time {
+	return f.session.StartedAt.Add(time.Duration(f.session.PlannedMinutes) * time.Minute)
+}
+
+// end 结束当前专注并保存
+func (f *FocusMode) end(status string, at time.Time) {
+	f.session.Status = status
+	f.session.EndedAt = at
+	if err := f.app.DB.UpdateFocusSession(f.session.ID, *f.session); err != nil {
+		f.app.ErrorLog.Println(err)
+	}
+	f.session = nil
+}
+
+// fishThreshold 连续摸鱼多久算摸鱼，专注时使用更短的等待时间
+func fishThreshold(focus bool) time.Duration {
+	rulesLock.RLock()
+	defer rulesLock.RUnlock()
+
+	if focus {
+		return time.Duration(focusWaitSeconds) * time.Second
+	}
+	return time.Duration(waitTime) * time.Minute
+}
+
+// durationText 时长显示为 5分钟 或 30秒
+func durationText(d time.Duration) string {
+	if d < time.Minute {
+		return fmt.Sprintf("%d秒", int(d.Seconds()))
+	}
+	return fmt.Sprintf("%d分钟", int(d.Minutes()))
+}
+
+// focusBar 番茄钟下方的专注倒计时和开始/提前结束按钮
+func (app *Config) focusBar() *fyne.Container {
+	app.FocusText = canvas.NewText("", nil)
+	app.FocusText.TextSize = 18
+
+	app.FocusButton = widget.NewButtonWithIcon("", nil, func() {
+		if app.Focus.Active() {
+			app.stopFocusDialog()
+		} else {
+			app.startFocusDialog()
+		}
+	})
+
+	app.refreshFocus()
+
+	return container.NewBorder(nil, nil, app.FocusText, app.FocusButton)
+}
+
+// startFocusDialog 填写专注时长，专注当前任务
+func (app *Config) startFocusDialog() {
+	rulesLock.RLock()
+	minutes := focusMinutes
+	rulesLock.RUnlock()
+
+	minutesEntry := intEntry(minutes, intRangeValidator(1, 600))
+	items := []*widget.FormItem{
+		widget.NewFormItem("专注时长(分钟)", minutesEntry),
+	}
+
+	dialog.ShowForm("开始专注", "开始", "取消", items, func(valid bool) {
+		if !valid {
+			return
+		}
+		minutes, _ := strconv.Atoi(minutesEntry.Text)
+		if err := app.Focus.Start(currentActiveTask(), minutes); err != nil {
+			dialog.ShowError(err, app.MainWindow)
+			app.ErrorLog.Println(err)
+			return
+		}
+		app.refreshFocus()
+	}, app.MainWindow)
+}
+
+// stopFocusDialog 确认后提前结束专注
+func (app *Config) stopFocusDialog() {
+	rulesLock.RLock()
+	penalty := focusExitPenalty
+	rulesLock.RUnlock()
+
+	message := "确定提前结束专注吗？"
+	if penalty > 0 {
+		message = fmt.Sprintf("提前结束专注将扣除%d积分，确定结束吗？", penalty)
+	}
+	dialog.ShowConfirm("提前结束专注", message, func(stop bool) {
+		if !stop {
+			return
+		}
+		app.Focus.Stop()
+		app.refreshFocus()
+	}, app.MainWindow)
+}
+
+// refreshFocus 刷新专注倒计时和按钮
+func (app *Config) refreshFocus() {
+	if app.FocusText == nil {
+		return
+	}
+
+	s, remaining, ok := app.Focus.Session()
+	app.FocusText.Text = focusText(s, remaining, ok)
+	app.FocusText.Refresh()
+
+	if ok {
+		app.FocusButton.SetText("提前结束")
+		app.FocusButton.SetIcon(theme.CancelIcon())
+	} else {
+		app.FocusButton.SetText("开始专注")
+		app.FocusButton.SetIcon(theme.VisibilityIcon())
+	}
+}
+
+// focusText 专注状态显示为 专注中 49:59 摸鱼0次
+func focusText(s repository.FocusSession, remaining time.Duration, ok bool) string {
+	if !ok {
+		return "未在专注"
+	}
+
+	if remaining < 0 {
+		remaining = 0
+	}
+	seconds := int(remaining.Round(time.Second).Seconds())
+	return fmt.Sprintf("专注中 %02d:%02d 摸鱼%d次", seconds/60, seconds%60, s.FishCount)
+}
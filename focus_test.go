@@ -0,0 +1,151 @@
+package main
+
+import (
+	"NoFish/repository"
+	"testing"
+	"time"
+)
+
+// newTestFocus 返回使用假时钟的专注模式和让时钟前进的函数
+func newTestFocus(start time.Time) (*FocusMode, func(d time.Duration)) {
+	clock := start
+	f := NewFocusMode(&testApp)
+	f.now = func() time.Time { return clock }
+
+	advance := func(d time.Duration) {
+		clock = clock.Add(d)
+	}
+	return f, advance
+}
+
+func TestFocusMode_Complete(t *testing.T) {
+	start := time.Date(2023, 3, 1, 9, 0, 0, 0, time.Local)
+	f, advance := newTestFocus(start)
+
+	if err := f.Start(7, 50); err != nil {
+		t.Fatal("start focus failed:", err)
+	}
+	if err := f.Start(7, 50); err == nil {
+		t.Error("expected error when starting twice")
+	}
+
+	advance(49 * time.Minute)
+	if f.Tick() {
+		t.Fatal("focus completed early")
+	}
+	if _, remaining, ok := f.Session(); !ok || remaining != time.Minute {
+		t.Errorf("expected 1m remaining, got %v", remaining)
+	}
+
+	advance(time.Minute)
+	if !f.Tick() || f.Active() {
+		t.Fatal("focus not completed after planned time")
+	}
+
+	sessions, _ := testApp.DB.FocusSessionsBetween(start, start.Add(time.Hour))
+	if len(sessions) != 1 {
+		t.Fatal("expected one focus session, got", sessions)
+	}
+	s := sessions[0]
+	if s.Status != repository.FocusCompleted || s.TaskID != 7 || !s.EndedAt.Equal(start.Add(50*time.Minute)) || s.PenaltyPoints != 0 {
+		t.Error("wrong completed session:", s)
+	}
+	sent := testNotifier.Notifications()
+	if len(sent) == 0 || sent[len(sent)-1].Kind != repository.NotificationFocus {
+		t.Error("focus completion not notified:", sent)
+	}
+}
+
+func TestFocusMode_StopCostsPoints(t *testing.T) {
+	restoreBalance(t)
+	start := time.Date(2023, 3, 2, 9, 0, 0, 0, time.Local)
+	f, advance := newTestFocus(start)
+
+	before, _ := testApp.DB.PointsBalance()
+	_ = f.Start(0, 50)
+	advance(10 * time.Minute)
+	if penalty := f.Stop(); penalty != focusExitPenalty {
+		t.Errorf("expected %d points penalty, got %d", focusExitPenalty, penalty)
+	}
+	if f.Active() {
+		t.Error("focus still active after stop")
+	}
+
+	after, _ := testApp.DB.PointsBalance()
+	if after != before-focusExitPenalty {
+		t.Errorf("expected %d points deducted, balance went from %d to %d", focusExitPenalty, before, after)
+	}
+	sessions, _ := testApp.DB.FocusSessionsBetween(start, start.Add(time.Hour))
+	if len(sessions) != 1 || sessions[0].Status != repository.FocusAbandoned || sessions[0].PenaltyPoints != focusExitPenalty ||
+		!sessions[0].EndedAt.Equal(start.Add(10*time.Minute)) {
+		t.Error("wrong abandoned session:", sessions)
+	}
+}
+
+func TestFocusMode_Restore(t *testing.T) {
+	start := time.Date(2023, 3, 3, 9, 0, 0, 0, time.Local)
+
+	// 退出时还在专注，重启时已经过了计划时长
+	expired, _ := testApp.DB.InsertFocusSession(repository.FocusSession{StartedAt: start, PlannedMinutes: 30, Status: repository.FocusActive})
+	f, _ := newTestFocus(start.Add(2 * time.Hour))
+	f.Restore()
+	if f.Active() {
+		t.Error("expired session restored as active")
+	}
+	sessions, _ := testApp.DB.FocusSessionsBetween(start, start.Add(time.Minute))
+	if len(sessions) != 1 || sessions[0].ID != expired.ID || sessions[0].Status != repository.FocusCompleted {
+		t.Error("expired session not completed:", sessions)
+	}
+
+	// 重启时还在计划时长内，继续专注
+	running, _ := testApp.DB.InsertFocusSession(repository.FocusSession{StartedAt: start.Add(3 * time.Hour), PlannedMinutes: 30, Status: repository.FocusActive})
+	f, _ = newTestFocus(start.Add(3*time.Hour + 10*time.Minute))
+	f.Restore()
+	s, remaining, ok := f.Session()
+	if !ok || s.ID != running.ID || remaining != 20*time.Minute {
+		t.Error("running session not restored:", s, remaining)
+	}
+	f.mu.Lock()
+	f.end(repository.FocusCompleted, start.Add(4*time.Hour))
+	f.mu.Unlock()
+}
+
+func TestFishChecker_Focus(t *testing.T) {
+	restoreBalance(t)
+	r, _ := testApp.DB.InsertRule(repository.Rule{Kind: repository.RuleBlock, Pattern: "bilibili", Enabled: true, FocusOnly: true})
+	_ = testApp.DB.SaveSetting(settingFocusWaitSeconds, "30")
+	testApp.loadFishRules()
+	defer func() {
+		_ = testApp.DB.DeleteRule(r.ID)
+		testApp.loadFishRules()
+	}()
+
+	day := time.Date(2023, 3, 6, 0, 0, 0, 0, time.Local)
+	c, step := newTestChecker(day, Window{Title: "bilibili 视频"})
+
+	// 平时只在专注时生效的规则不生效
+	step(2)
+	if c.FishDuration() != 0 {
+		t.Fatal("focus-only rule applied outside focus:", c.FishDuration())
+	}
+
+	focus := testApp.Focus
+	defer func() { testApp.Focus = focus }()
+	testApp.Focus, _ = newTestFocus(day.Add(10 * time.Hour))
+	_ = testApp.Focus.Start(0, 50)
+	defer testApp.Focus.Stop()
+
+	// 专注时 30 秒就算摸鱼：第 2 次采样时连续摸鱼 40 秒
+	step(1)
+	if c.FishDuration() != sampleInterval {
+		t.Fatal("focus-only rule not applied during focus:", c.FishDuration())
+	}
+	step(1)
+	s, _ := testApp.DB.GetSummaryByDay("2023-03-06")
+	if s.FishCount != 1 {
+		t.Errorf("expected 1 fish event within focus wait, got %d", s.FishCount)
+	}
+	if session, _, _ := testApp.Focus.Session(); session.FishCount != 1 {
+		t.Error("fish not recorded on focus session:", session)
+	}
+}
@@ -0,0 +1,264 @@
+package main
+
+import (
+	"NoFish/repository"
+	"fmt"
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/storage"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"strconv"
+	"time"
+)
+
+// 热力图默认统计最近几周
+var heatmapWeeks = 4
+
+// 热力图可选的周数
+var heatmapWeekOptions = []int{1, 4, 8, 12}
+
+// 热力图配色：没有数据为灰色，摸鱼占比从 0 到 1 由浅到深
+var (
+	heatmapEmpty = color.RGBA{R: 0xeb, G: 0xed, B: 0xf0, A: 0xff}
+	heatmapLow   = color.RGBA{R: 0xfd, G: 0xe0, B: 0xdc, A: 0xff}
+	heatmapHigh  = color.RGBA{R: 0xb7, G: 0x1c, B: 0x1c, A: 0xff}
+	heatmapText  = color.RGBA{R: 0x60, G: 0x60, B: 0x60, A: 0xff}
+)
+
+// 热力图按周一到周日排列
+var heatmapWeekdays = []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday, time.Saturday, time.Sunday}
+
+// 导出图片里的星期，图片只能画 ASCII
+var heatmapWeekdayShort = []string{"Sun", "Mon", "Tue", "Wed", "Thu", "Fri", "Sat"}
+
+// Heatmap 每个星期几、每个小时的工作和摸鱼时长，下标为 [time.Weekday][小时]，单位秒
+type Heatmap struct {
+	Productive  [7][24]int64
+	Distracting [7][24]int64
+}
+
+// Rate 摸鱼时长占工作加摸鱼时长的比例，没有数据返回 -1
+func (h *Heatmap) Rate(weekday time.Weekday, hour int) float64 {
+	p, d := h.Productive[weekday][hour], h.Distracting[weekday][hour]
+	if p+d == 0 {
+		return -1
+	}
+	return float64(d) / float64(p+d)
+}
+
+// buildHeatmap 汇总 [from, to) 内的活动，跨小时的活动按时长分到各个小时
+func buildHeatmap(activities []repository.Activity, from, to time.Time) *Heatmap {
+	h := &Heatmap{}
+	for _, a := range activities {
+		var bucket *[7][24]int64
+		switch a.Category {
+		case repository.CategoryProductive:
+			bucket = &h.Productive
+		case repository.CategoryDistracting:
+			bucket = &h.Distracting
+		default:
+			continue
+		}
+
+		start, end := a.StartedAt, a.EndedAt
+		if start.Before(from) {
+			start = from
+		}
+		if end.After(to) {
+			end = to
+		}
+		for start.Before(end) {
+			// Truncate 按 UTC 取整，半小时时区会错开，按本地时间算下一个整点
+			next := time.Date(start.Year(), start.Month(), start.Day(), start.Hour()+1, 0, 0, 0, start.Location())
+			if next.After(end) {
+				next = end
+			}
+			bucket[start.Weekday()][start.Hour()] += int64(next.Sub(start).Seconds())
+			start = next
+		}
+	}
+	return h
+}
+
+// loadHeatmap 最近 weeks 周（含今天）的热力图
+func (app *Config) loadHeatmap(weeks int, now time.Time) (*Heatmap, error) {
+	to := time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, now.Location())
+	from := to.AddDate(0, 0, -7*weeks)
+
+	activities, err := app.DB.ActivitiesBetween(from, to)
+	if err != nil {
+		return nil, err
+	}
+	return buildHeatmap(activities, from, to), nil
+}
+
+// heatmapColor 摸鱼占比对应的颜色
+func heatmapColor(rate float64) color.RGBA {
+	if rate < 0 {
+		return heatmapEmpty
+	}
+	mix := func(a, b uint8) uint8 {
+		return uint8(float64(a) + (float64(b)-float64(a))*rate)
+	}
+	return color.RGBA{
+		R: mix(heatmapLow.R, heatmapHigh.R),
+		G: mix(heatmapLow.G, heatmapHigh.G),
+		B: mix(heatmapLow.B, heatmapHigh.B),
+		A: 0xff,
+	}
+}
+
+// 导出图片的尺寸，单位像素
+const (
+	heatmapCell   = 16
+	heatmapGap    = 2
+	heatmapLeft   = 32
+	heatmapTop    = 16
+	heatmapMargin = 8
+)
+
+// Render 画成图片：左边是星期，上面每 3 小时一个刻度
+func (h *Heatmap) Render() *image.RGBA {
+	step := heatmapCell + heatmapGap
+	width := heatmapLeft + 24*step + heatmapMargin
+	height := heatmapTop + 7*step + heatmapMargin
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	fillRect(img, img.Bounds(), chartBackground)
+
+	drawer := &font.Drawer{
+		Dst:  img,
+		Src:  image.NewUniform(heatmapText),
+		Face: basicfont.Face7x13,
+	}
+	for hour := 0; hour < 24; hour += 3 {
+		drawer.Dot = fixed.P(heatmapLeft+hour*step, heatmapTop-4)
+		drawer.DrawString(strconv.Itoa(hour))
+	}
+	for row, weekday := range heatmapWeekdays {
+		y := heatmapTop + row*step
+		drawer.Dot = fixed.P(4, y+heatmapCell-4)
+		drawer.DrawString(heatmapWeekdayShort[weekday])
+		for hour := 0; hour < 24; hour++ {
+			x := heatmapLeft + hour*step
+			fillRect(img, image.Rect(x, y, x+heatmapCell, y+heatmapCell), heatmapColor(h.Rate(weekday, hour)))
+		}
+	}
+	return img
+}
+
+// WritePNG 导出为 PNG
+func (h *Heatmap) WritePNG(w io.Writer) error {
+	return png.Encode(w, h.Render())
+}
+
+// heatmapView 热力图面板：可以选择统计的周数，可以导出 PNG
+func (app *Config) heatmapView() fyne.CanvasObject {
+	grid := container.NewGridWithColumns(25)
+	var current *Heatmap
+	weeks := heatmapWeeks
+
+	refresh := func() {
+		h, err := app.loadHeatmap(weeks, time.Now())
+		if err != nil {
+			app.ErrorLog.Println(err)
+			return
+		}
+		current = h
+		grid.Objects = heatmapCells(h)
+		grid.Refresh()
+	}
+
+	var options []string
+	for _, w := range heatmapWeekOptions {
+		options = append(options, fmt.Sprintf("最近%d周", w))
+	}
+	weeksSelect := widget.NewSelect(options, func(selected string) {
+		for i, o := range options {
+			if o == selected {
+				weeks = heatmapWeekOptions[i]
+			}
+		}
+		refresh()
+	})
+	weeksSelect.SetSelected(fmt.Sprintf("最近%d周", weeks))
+
+	exportButton := widget.NewButtonWithIcon("导出PNG", theme.DocumentSaveIcon(), func() {
+		if current != nil {
+			app.exportHeatmap(current)
+		}
+	})
+
+	legend := container.NewHBox(widget.NewLabel("摸鱼占比 低"))
+	for _, rate := range []float64{0, 0.25, 0.5, 0.75, 1} {
+		legend.Add(container.NewCenter(heatmapSwatch(heatmapColor(rate))))
+	}
+	legend.Add(widget.NewLabel("高"))
+	legend.Add(container.NewCenter(heatmapSwatch(heatmapEmpty)))
+	legend.Add(widget.NewLabel("没有数据"))
+
+	top := container.NewHBox(weeksSelect, legend, layout.NewSpacer(), exportButton)
+	return container.NewBorder(top, nil, nil, nil, container.NewVBox(grid))
+}
+
+// heatmapCells 表头一行小时，之后每行一个星期几，每行第一格是星期
+func heatmapCells(h *Heatmap) []fyne.CanvasObject {
+	cells := []fyne.CanvasObject{canvas.NewText("", nil)}
+	for hour := 0; hour < 24; hour++ {
+		label := ""
+		if hour%3 == 0 {
+			label = strconv.Itoa(hour)
+		}
+		text := canvas.NewText(label, heatmapText)
+		text.TextSize = 10
+		cells = append(cells, text)
+	}
+	for _, weekday := range heatmapWeekdays {
+		text := canvas.NewText(weekdayText[weekday], heatmapText)
+		text.TextSize = 10
+		cells = append(cells, text)
+		for hour := 0; hour < 24; hour++ {
+			cells = append(cells, heatmapSwatch(heatmapColor(h.Rate(weekday, hour))))
+		}
+	}
+	return cells
+}
+
+func heatmapSwatch(c color.Color) *canvas.Rectangle {
+	r := canvas.NewRectangle(c)
+	r.SetMinSize(fyne.NewSize(heatmapCell, heatmapCell))
+	return r
+}
+
+// exportHeatmap 选择保存位置，导出 PNG
+func (app *Config) exportHeatmap(h *Heatmap) {
+	save := dialog.NewFileSave(func(writer fyne.URIWriteCloser, err error) {
+		if err != nil {
+			dialog.ShowError(err, app.MainWindow)
+			app.ErrorLog.Println(err)
+			return
+		}
+		if writer == nil {
+			return
+		}
+		defer writer.Close()
+
+		if err := h.WritePNG(writer); err != nil {
+			dialog.ShowError(err, app.MainWindow)
+			app.ErrorLog.Println(err)
+		}
+	}, app.MainWindow)
+	save.SetFileName("heatmap.png")
+	save.SetFilter(storage.NewExtensionFileFilter([]string{".png"}))
+	save.Show()
+}
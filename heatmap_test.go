@@ -0,0 +1,114 @@
+package main
+
+import (
+	"NoFish/repository"
+	"bytes"
+	"image/png"
+	"testing"
+	"time"
+)
+
+func TestBuildHeatmap(t *testing.T) {
+	// 2023-05-08 是周一
+	monday := time.Date(2023, 5, 8, 0, 0, 0, 0, time.Local)
+	from, to := monday, monday.AddDate(0, 0, 7)
+	activities := []repository.Activity{
+		// 跨小时的活动分到两个小时
+		{StartedAt: monday.Add(9*time.Hour + 40*time.Minute), EndedAt: monday.Add(10*time.Hour + 10*time.Minute), Category: repository.CategoryDistracting},
+		{StartedAt: monday.Add(10*time.Hour + 10*time.Minute), EndedAt: monday.Add(11 * time.Hour), Category: repository.CategoryProductive},
+		// 离开和中性不算
+		{StartedAt: monday.Add(12 * time.Hour), EndedAt: monday.Add(13 * time.Hour), Category: repository.CategoryIdle},
+		{StartedAt: monday.Add(13 * time.Hour), EndedAt: monday.Add(14 * time.Hour), Category: repository.CategoryNeutral},
+		// 范围之前的部分被截掉
+		{StartedAt: monday.Add(-30 * time.Minute), EndedAt: monday.Add(15 * time.Minute), Category: repository.CategoryDistracting},
+	}
+
+	h := buildHeatmap(activities, from, to)
+	if h.Distracting[time.Monday][9] != 20*60 || h.Distracting[time.Monday][10] != 10*60 || h.Productive[time.Monday][10] != 50*60 {
+		t.Error("activity not split by hour:", h.Distracting[time.Monday][9:11], h.Productive[time.Monday][10])
+	}
+	if h.Distracting[time.Monday][0] != 15*60 || h.Distracting[time.Sunday][23] != 0 {
+		t.Error("activity outside range not clipped:", h.Distracting[time.Monday][0], h.Distracting[time.Sunday][23])
+	}
+
+	if r := h.Rate(time.Monday, 9); r != 1 {
+		t.Error("expected rate 1 for fishing only hour, got", r)
+	}
+	if r := h.Rate(time.Monday, 10); r != 10.0/60 {
+		t.Error("wrong mixed rate:", r)
+	}
+	if r := h.Rate(time.Monday, 12); r != -1 {
+		t.Error("expected no data for idle hour, got", r)
+	}
+}
+
+func TestBuildHeatmap_HalfHourZone(t *testing.T) {
+	// 印度标准时间 UTC+5:30，2023-05-08 是周一
+	ist := time.FixedZone("IST", 5*3600+30*60)
+	monday := time.Date(2023, 5, 8, 0, 0, 0, 0, ist)
+	activities := []repository.Activity{
+		{StartedAt: monday.Add(9*time.Hour + 40*time.Minute), EndedAt: monday.Add(10*time.Hour + 10*time.Minute), Category: repository.CategoryDistracting},
+	}
+
+	h := buildHeatmap(activities, monday, monday.AddDate(0, 0, 7))
+	if h.Distracting[time.Monday][9] != 20*60 || h.Distracting[time.Monday][10] != 10*60 {
+		t.Error("activity not split at local hours:", h.Distracting[time.Monday][9:11])
+	}
+}
+
+func TestHeatmapColor(t *testing.T) {
+	if c := heatmapColor(-1); c != heatmapEmpty {
+		t.Error("expected empty color, got", c)
+	}
+	if c := heatmapColor(0); c != heatmapLow {
+		t.Error("expected low color, got", c)
+	}
+	if c := heatmapColor(1); c != heatmapHigh {
+		t.Error("expected high color, got", c)
+	}
+}
+
+func TestHeatmap_WritePNG(t *testing.T) {
+	h := &Heatmap{}
+	h.Distracting[time.Tuesday][14] = 600
+
+	var buf bytes.Buffer
+	if err := h.WritePNG(&buf); err != nil {
+		t.Fatal("write png failed:", err)
+	}
+	img, err := png.Decode(&buf)
+	if err != nil {
+		t.Fatal("decode png failed:", err)
+	}
+
+	step := heatmapCell + heatmapGap
+	if img.Bounds().Dx() != heatmapLeft+24*step+heatmapMargin || img.Bounds().Dy() != heatmapTop+7*step+heatmapMargin {
+		t.Error("wrong image size:", img.Bounds())
+	}
+	// 周二是第 2 行
+	x, y := heatmapLeft+14*step+1, heatmapTop+1*step+1
+	if r, g, b, _ := img.At(x, y).RGBA(); uint8(r>>8) != heatmapHigh.R || uint8(g>>8) != heatmapHigh.G || uint8(b>>8) != heatmapHigh.B {
+		t.Error("expected fishing hour drawn dark, got", img.At(x, y))
+	}
+	if r, _, _, _ := img.At(x+step, y).RGBA(); uint8(r>>8) != heatmapEmpty.R {
+		t.Error("expected empty hour drawn gray, got", img.At(x+step, y))
+	}
+}
+
+func TestApp_loadHeatmap(t *testing.T) {
+	now := time.Date(2023, 5, 20, 18, 0, 0, 0, time.Local)
+	_, _ = testApp.DB.RecordActivity(repository.Activity{StartedAt: now.AddDate(0, 0, -14), EndedAt: now.AddDate(0, 0, -14).Add(time.Hour), Title: "知乎", Category: repository.CategoryDistracting, Samples: 1})
+	_, _ = testApp.DB.RecordActivity(repository.Activity{StartedAt: now.Add(-2 * time.Hour), EndedAt: now.Add(-time.Hour), Title: "知乎", Category: repository.CategoryDistracting, Samples: 1})
+
+	h, err := testApp.loadHeatmap(1, now)
+	if err != nil {
+		t.Fatal("load heatmap failed:", err)
+	}
+	if h.Distracting[time.Saturday][16] != 3600 || h.Distracting[time.Saturday][18] != 0 {
+		t.Error("wrong heatmap for 1 week:", h.Distracting[time.Saturday][15:19])
+	}
+	h, _ = testApp.loadHeatmap(4, now)
+	if h.Distracting[time.Saturday][16] != 3600 || h.Distracting[time.Saturday][18] != 3600 {
+		t.Error("wrong heatmap for 4 weeks:", h.Distracting[time.Saturday][15:19])
+	}
+}
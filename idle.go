@@ -0,0 +1,24 @@
+package main
+
+import "time"
+
+// IdleProbe 读取距离最后一次键盘、鼠标输入过了多久
+type IdleProbe interface {
+	IdleTime() time.Duration
+}
+
+// IdleFunc 把普通函数当作 IdleProbe 使用
+type IdleFunc func() time.Duration
+
+func (f IdleFunc) IdleTime() time.Duration {
+	return f()
+}
+
+// isIdle 是否已经离开电脑
+func isIdle(p IdleProbe) bool {
+	rulesLock.RLock()
+	threshold := time.Duration(idleThreshold) * time.Minute
+	rulesLock.RUnlock()
+
+	return p.IdleTime() >= threshold
+}
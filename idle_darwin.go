@@ -0,0 +1,41 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// systemIdle 通过 ioreg 读取 IOHIDSystem 的 HIDIdleTime
+type systemIdle struct{}
+
+func (systemIdle) IdleTime() time.Duration {
+	out, err := exec.Command("ioreg", "-c", "IOHIDSystem").Output()
+	if err != nil {
+		return 0
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.Contains(line, `"HIDIdleTime"`) {
+			continue
+		}
+		fields := strings.Split(line, "=")
+		ns, err := strconv.ParseInt(strings.TrimSpace(fields[len(fields)-1]), 10, 64)
+		if err != nil {
+			return 0
+		}
+		return time.Duration(ns)
+	}
+	return 0
+}
+
+// idleSupported 能否检测离开电脑
+func idleSupported() error {
+	_, err := exec.LookPath("ioreg")
+	return err
+}
@@ -0,0 +1,43 @@
+//go:build linux
+
+package main
+
+import (
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// systemIdle 通过 xprintidle 读取 X11 的空闲时间
+type systemIdle struct{}
+
+func (systemIdle) IdleTime() time.Duration {
+	out, err := exec.Command("xprintidle").Output()
+	if err != nil {
+		return 0
+	}
+	idle, err := parseIdleMillis(out)
+	if err != nil {
+		return 0
+	}
+	return idle
+}
+
+// parseIdleMillis xprintidle 输出的是毫秒数
+func parseIdleMillis(out []byte) (time.Duration, error) {
+	ms, err := strconv.ParseInt(strings.TrimSpace(string(out)), 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(ms) * time.Millisecond, nil
+}
+
+// idleSupported 能否检测离开电脑，没有安装 xprintidle 或者不在 X11 下时不能
+func idleSupported() error {
+	if _, err := exec.Command("xprintidle").Output(); err != nil {
+		return fmt.Errorf("需要在 X11 下安装 xprintidle 才能检测离开电脑: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,17 @@
+//go:build linux
+
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseIdleMillis(t *testing.T) {
+	if d, err := parseIdleMillis([]byte("183250\n")); err != nil || d != 183250*time.Millisecond {
+		t.Error("wrong idle time:", d, err)
+	}
+	if _, err := parseIdleMillis([]byte("couldn't open display")); err == nil {
+		t.Error("expected error for invalid output")
+	}
+}
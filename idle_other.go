@@ -0,0 +1,20 @@
+//go:build !darwin && !windows && !linux
+
+package main
+
+import (
+	"errors"
+	"time"
+)
+
+// systemIdle 这些系统上读取不到空闲时间，一直视为在电脑前
+type systemIdle struct{}
+
+func (systemIdle) IdleTime() time.Duration {
+	return 0
+}
+
+// idleSupported 能否检测离开电脑
+func idleSupported() error {
+	return errors.New("当前系统不支持检测离开电脑")
+}
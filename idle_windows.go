@@ -0,0 +1,43 @@
+//go:build windows
+
+package main
+
+import (
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+var (
+	user32               = syscall.NewLazyDLL("user32.dll")
+	kernel32             = syscall.NewLazyDLL("kernel32.dll")
+	procGetLastInputInfo = user32.NewProc("GetLastInputInfo")
+	procGetTickCount     = kernel32.NewProc("GetTickCount")
+)
+
+// lastInputInfo 对应 LASTINPUTINFO
+type lastInputInfo struct {
+	cbSize uint32
+	dwTime uint32
+}
+
+// systemIdle 通过 GetLastInputInfo 读取最后一次输入的时间
+type systemIdle struct{}
+
+func (systemIdle) IdleTime() time.Duration {
+	info := lastInputInfo{cbSize: uint32(unsafe.Sizeof(lastInputInfo{}))}
+	if ok, _, _ := procGetLastInputInfo.Call(uintptr(unsafe.Pointer(&info))); ok == 0 {
+		return 0
+	}
+	// 两个都是开机以来的毫秒数，用 uint32 相减，开机 49 天后回绕也不会出错
+	now, _, _ := procGetTickCount.Call()
+	return time.Duration(uint32(now)-info.dwTime) * time.Millisecond
+}
+
+// idleSupported 能否检测离开电脑
+func idleSupported() error {
+	if err := procGetLastInputInfo.Find(); err != nil {
+		return err
+	}
+	return procGetTickCount.Find()
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/app"
+	"fyne.io/fyne/v2/canvas"
 	"fyne.io/fyne/v2/widget"
 	"github.com/flopp/go-findfont"
 	_ "github.com/glebarez/go-sqlite"
@@ -13,6 +14,7 @@ import (
 	"log"
 	"net/http"
 	"os"
+	"time"
 )
 
 type App struct {
@@ -32,14 +34,45 @@ type Config struct {
 	// 存放摸鱼次数
 	FishCount   int
 	FinishCount int
-	PrizeCount  int
+	// 今日工作、中性、摸鱼窗口的时长
+	ProductiveTime  time.Duration
+	NeutralTime     time.Duration
+	DistractingTime time.Duration
+	// 当前积分，来自积分流水
+	PrizeCount int
 	// 数据库
 	DB repository.Repository
 	// 任务相关
-	Tasks       [][]interface{}
-	TasksTable  *widget.Table
-	Prizes      [][]interface{}
-	PrizesTable *widget.Table
+	Tasks               [][]interface{}
+	TasksTable          *widget.Table
+	CompletedTasks      [][]interface{}
+	CompletedTasksTable *widget.Table
+	Prizes              [][]interface{}
+	PrizesTable         *widget.Table
+
+	// 摸鱼检查
+	Checker *FishChecker
+	// 休息提醒
+	Rest *RestReminder
+	// 喝水、久坐等提醒
+	Reminders *ReminderScheduler
+	// 自动生成报告
+	Reports *ReportScheduler
+	// 通知和通知记录
+	Notifier           Notifier
+	Notifications      []repository.Notification
+	NotificationsTable *widget.Table
+	// 番茄钟和倒计时
+	Pomodoro      *PomodoroTimer
+	PomodoroText  *canvas.Text
+	PomodoroPause *widget.Button
+	// 专注模式和倒计时
+	Focus       *FocusMode
+	FocusText   *canvas.Text
+	FocusButton *widget.Button
+	// 统计面板，StatsRange 为当前粒度
+	StatsRange  string
+	StatsCharts *fyne.Container
 
 	// 添加任务临时存放
 	appTask *AppTask
@@ -48,16 +81,33 @@ type Config struct {
 var myApp Config
 
 func main() {
+	loadFont()
 
 	// 创建应用
 	app := app.NewWithID("com.earl")
 	myApp.App = app
 	// 窗口初始化
 	initApp(app)
+	// 检测不到离开电脑时，离开也会按在电脑前计算
+	if err := idleSupported(); err != nil {
+		myApp.ErrorLog.Println("无法检测离开电脑:", err)
+	}
 	// 检查是否摸鱼
-	go fishCheck()
+	myApp.Checker = NewFishChecker(&myApp, robotgoProbe{}, systemIdle{})
+	go myApp.Checker.Run()
 	// 提醒休息一下，不管是不是在工作
-	go takeARest()
+	myApp.Rest = NewRestReminder(&myApp, systemIdle{})
+	go myApp.Rest.Run()
+	// 喝水、久坐等自定义提醒
+	myApp.Reminders = NewReminderScheduler(&myApp, systemIdle{})
+	go myApp.Reminders.Run()
+	// 番茄钟倒计时
+	go myApp.Pomodoro.Run()
+	// 专注倒计时
+	go myApp.Focus.Run()
+	// 下班后自动生成日报、周报
+	myApp.Reports = NewReportScheduler(&myApp, myApp.reportDir())
+	go myApp.Reports.Run()
 	// 启动
 	myApp.MainWindow.ShowAndRun()
 }
@@ -80,12 +130,18 @@ func initApp(app fyne.App) {
 		log.Panic(err)
 	}
 	myApp.setupDB(sqlDB)
+	// 加载摸鱼检测规则
+	myApp.loadFishRules()
+	myApp.Notifier = myApp.setupNotifier()
+	myApp.Pomodoro = NewPomodoroTimer(&myApp)
+	myApp.Focus = NewFocusMode(&myApp)
+	myApp.Focus.Restore()
 	// ui初始化
 	myApp.makeUI()
 }
 
-// 初始化中文字体文件
-func init() {
+// loadFont 初始化中文字体文件，要在创建应用之前调用
+func loadFont() {
 	fontPath, err := findfont.Find("ShangShouJianSongXianXiTi-2.ttf")
 	if err != nil {
 		panic(err)
@@ -116,6 +172,21 @@ func (app *Config) setupDB(sqlDB *sql.DB) {
 	}
 }
 
+// setupNotifier 通知同时发到桌面、窗口里的通知记录和日志文件
+func (app *Config) setupNotifier() Notifier {
+	notifiers := Notifiers{desktopNotifier{}, historyNotifier{app}}
+
+	path := app.App.Storage().RootURI().Path() + "/notifications.log"
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		app.ErrorLog.Println(err)
+		return notifiers
+	}
+	notifiers = append(notifiers, logNotifier{log.New(file, "", log.Ldate|log.Ltime)})
+
+	return notifiers
+}
+
 func (app *Config) connectSQL() (*sql.DB, error) {
 	path := ""
 
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"NoFish/repository"
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"log"
+	"sync"
+	"time"
+)
+
+// 通知记录面板显示的条数
+var notificationHistoryLimit = 200
+
+var notificationKindText = map[string]string{
+	repository.NotificationFish:     "摸鱼",
+	repository.NotificationRest:     "休息",
+	repository.NotificationReminder: "提醒",
+	repository.NotificationPomodoro: "番茄钟",
+	repository.NotificationFocus:    "专注",
+	repository.NotificationReport:   "报告",
+}
+
+// Notifier 发出一条通知，通知已经保存到数据库，有 ID
+type Notifier interface {
+	Notify(n repository.Notification)
+}
+
+// Notifiers 把通知依次发给每一个 Notifier
+type Notifiers []Notifier
+
+func (ns Notifiers) Notify(n repository.Notification) {
+	for _, notifier := range ns {
+		notifier.Notify(n)
+	}
+}
+
+// desktopNotifier 系统通知
+type desktopNotifier struct{}
+
+func (desktopNotifier) Notify(n repository.Notification) {
+	fyne.CurrentApp().SendNotification(&fyne.Notification{
+		Title:   n.Title,
+		Content: n.Content,
+	})
+}
+
+// historyNotifier 刷新窗口里的通知记录
+type historyNotifier struct {
+	app *Config
+}
+
+func (h historyNotifier) Notify(repository.Notification) {
+	h.app.refreshNotifications()
+}
+
+// logNotifier 把通知写到日志
+type logNotifier struct {
+	logger *log.Logger
+}
+
+func (l logNotifier) Notify(n repository.Notification) {
+	l.logger.Printf("[%s] %s: %s\n", n.Kind, n.Title, n.Content)
+}
+
+// RecordingNotifier 记录收到的通知，用于测试
+type RecordingNotifier struct {
+	mu            sync.Mutex
+	notifications []repository.Notification
+}
+
+func (r *RecordingNotifier) Notify(n repository.Notification) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.notifications = append(r.notifications, n)
+}
+
+// Notifications 收到的通知，按收到的顺序
+func (r *RecordingNotifier) Notifications() []repository.Notification {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var all []repository.Notification
+	all = append(all, r.notifications...)
+	return all
+}
+
+// notify 保存通知记录，再发给所有 Notifier
+func (app *Config) notify(kind, title, content string) repository.Notification {
+	n := repository.Notification{
+		Kind:      kind,
+		Title:     title,
+		Content:   content,
+		CreatedAt: time.Now(),
+	}
+	saved, err := app.DB.InsertNotification(n)
+	if err != nil {
+		app.ErrorLog.Println(err)
+	} else {
+		n = *saved
+	}
+
+	if app.Notifier != nil {
+		app.Notifier.Notify(n)
+	}
+	return n
+}
+
+// dismissNotification 标记通知已忽略
+func (app *Config) dismissNotification(id int64) {
+	if id == 0 {
+		return
+	}
+	if err := app.DB.DismissNotification(id, time.Now()); err != nil {
+		app.ErrorLog.Println(err)
+	}
+	app.refreshNotifications()
+}
+
+// notificationsTab 通知记录
+func (app *Config) notificationsTab() *fyne.Container {
+	app.Notifications = app.recentNotifications()
+
+	header := []string{"时间", "类型", "标题", "内容", "状态"}
+	app.NotificationsTable = widget.NewTable(
+		func() (int, int) {
+			return len(app.Notifications) + 1, len(header)
+		},
+		func() fyne.CanvasObject {
+			return container.NewVBox(widget.NewLabel(""))
+		},
+		func(i widget.TableCellID, o fyne.CanvasObject) {
+			if i.Row == 0 {
+				o.(*fyne.Container).Objects = []fyne.CanvasObject{widget.NewLabel(header[i.Col])}
+				return
+			}
+			n := app.Notifications[i.Row-1]
+			var w fyne.CanvasObject
+			switch i.Col {
+			case 0:
+				w = widget.NewLabel(n.CreatedAt.Format("01-02 15:04"))
+			case 1:
+				w = widget.NewLabel(notificationKindText[n.Kind])
+			case 2:
+				w = widget.NewLabel(n.Title)
+			case 3:
+				w = widget.NewLabel(n.Content)
+			case 4:
+				if n.DismissedAt.IsZero() {
+					w = widget.NewButtonWithIcon("忽略", theme.VisibilityOffIcon(), func() {
+						app.dismissNotification(n.ID)
+					})
+				} else {
+					w = widget.NewLabel("已忽略")
+				}
+			}
+			o.(*fyne.Container).Objects = []fyne.CanvasObject{w}
+		})
+
+	colWidths := []float32{100, 60, 100, 380, 90}
+	for i := 0; i < len(colWidths); i++ {
+		app.NotificationsTable.SetColumnWidth(i, colWidths[i])
+	}
+
+	return container.NewBorder(nil, nil, nil, nil, container.NewAdaptiveGrid(1, app.NotificationsTable))
+}
+
+// refreshNotifications 刷新通知记录
+func (app *Config) refreshNotifications() {
+	if app.NotificationsTable == nil {
+		return
+	}
+	app.Notifications = app.recentNotifications()
+	app.NotificationsTable.Refresh()
+}
+
+func (app *Config) recentNotifications() []repository.Notification {
+	all, err := app.DB.RecentNotifications(notificationHistoryLimit)
+	if err != nil {
+		app.ErrorLog.Println(err)
+	}
+	return all
+}
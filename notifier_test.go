@@ -0,0 +1,35 @@
+package main
+
+import (
+	"NoFish/repository"
+	"testing"
+)
+
+func TestNotifiers_FanOut(t *testing.T) {
+	a, b := &RecordingNotifier{}, &RecordingNotifier{}
+	Notifiers{a, b}.Notify(repository.Notification{Title: "摸鱼警告"})
+
+	if len(a.Notifications()) != 1 || len(b.Notifications()) != 1 {
+		t.Error("notification not sent to every notifier:", a.Notifications(), b.Notifications())
+	}
+}
+
+func TestApp_notify(t *testing.T) {
+	before := len(testNotifier.Notifications())
+
+	n := testApp.notify(repository.NotificationRest, "休息一下", "看电脑20分钟了")
+	if n.ID == 0 {
+		t.Fatal("notification not saved")
+	}
+
+	sent := testNotifier.Notifications()
+	if len(sent) != before+1 || sent[len(sent)-1].ID != n.ID || sent[len(sent)-1].Kind != repository.NotificationRest {
+		t.Error("notification not sent:", sent)
+	}
+
+	testApp.dismissNotification(n.ID)
+	recent, _ := testApp.DB.RecentNotifications(1)
+	if len(recent) != 1 || recent[0].ID != n.ID || recent[0].DismissedAt.IsZero() {
+		t.Error("dismissal not saved:", recent)
+	}
+}
@@ -0,0 +1,336 @@
+package main
+
+import (
+	"NoFish/repository"
+	"fmt"
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"sync"
+	"time"
+)
+
+// 番茄钟阶段
+const (
+	PhaseIdle       = "idle"
+	PhaseWork       = "work"
+	PhaseShortBreak = "short_break"
+	PhaseLongBreak  = "long_break"
+)
+
+var phaseText = map[string]string{
+	PhaseIdle:       "番茄钟未开始",
+	PhaseWork:       "专注",
+	PhaseShortBreak: "短休息",
+	PhaseLongBreak:  "长休息",
+}
+
+// 番茄钟各阶段时长，单位分钟
+var pomodoroWork = 25
+var pomodoroShortBreak = 5
+var pomodoroLongBreak = 15
+
+// 每完成几个专注进行一次长休息
+var pomodoroLongEvery = 4
+
+// 是否发送番茄钟提醒
+var notifyPomodoro = true
+
+// phaseLength 当前配置下某个阶段的时长
+func phaseLength(phase string) time.Duration {
+	rulesLock.RLock()
+	defer rulesLock.RUnlock()
+
+	switch phase {
+	case PhaseWork:
+		return time.Duration(pomodoroWork) * time.Minute
+	case PhaseShortBreak:
+		return time.Duration(pomodoroShortBreak) * time.Minute
+	case PhaseLongBreak:
+		return time.Duration(pomodoroLongBreak) * time.Minute
+	}
+	return 0
+}
+
+// PomodoroState 番茄钟当前状态
+type PomodoroState struct {
+	Phase     string
+	Remaining time.Duration
+	Paused    bool
+	// 本轮已完成的专注数，长休息后清零
+	Cycles int
+	TaskID int64
+}
+
+// PomodoroTimer 番茄钟：专注和休息交替进行，每完成 pomodoroLongEvery 个专注
+// 进行一次长休息，完成的专注记录到数据库
+type PomodoroTimer struct {
+	mu  sync.Mutex
+	app *Config
+	// 时钟，测试时可以替换
+	now func() time.Time
+
+	state PomodoroState
+	// 当前阶段开始时间
+	phaseStart time.Time
+	// 当前阶段已经走过的时长，不含暂停
+	spent time.Duration
+	// 上一次更新剩余时间的时间
+	lastTick time.Time
+}
+
+// NewPomodoroTimer 未开始的番茄钟
+func NewPomodoroTimer(app *Config) *PomodoroTimer {
+	return &PomodoroTimer{
+		app:   app,
+		now:   time.Now,
+		state: PomodoroState{Phase: PhaseIdle},
+	}
+}
+
+// Run 每秒更新一次番茄钟和倒计时
+func (p *PomodoroTimer) Run() {
+	for range time.Tick(time.Second) {
+		p.Tick()
+		p.app.refreshPomodoro()
+	}
+}
+
+// Start 为任务开始一轮新的番茄钟，taskID 为 0 表示不关联任务
+func (p *PomodoroTimer) Start(taskID int64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.state = PomodoroState{TaskID: taskID}
+	p.enter(PhaseWork)
+}
+
+// SetTask 之后完成的专注记到 taskID 上
+func (p *PomodoroTimer) SetTask(taskID int64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.state.TaskID = taskID
+}
+
+// Pause 暂停倒计时
+func (p *PomodoroTimer) Pause() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.state.Phase == PhaseIdle || p.state.Paused {
+		return
+	}
+	p.update()
+	p.state.Paused = true
+}
+
+// Resume 继续倒计时
+func (p *PomodoroTimer) Resume() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if !p.state.Paused {
+		return
+	}
+	p.state.Paused = false
+	p.lastTick = p.now()
+}
+
+// Skip 跳过当前阶段，跳过的专注不计数
+func (p *PomodoroTimer) Skip() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.state.Phase == PhaseIdle {
+		return
+	}
+	p.advance(false)
+}
+
+// Stop 结束番茄钟，当前未完成的专注不计数
+func (p *PomodoroTimer) Stop() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.state = PomodoroState{Phase: PhaseIdle}
+}
+
+// Tick 更新剩余时间，时间到了进入下一阶段
+func (p *PomodoroTimer) Tick() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.state.Phase == PhaseIdle || p.state.Paused {
+		return
+	}
+	p.update()
+	if p.state.Remaining <= 0 {
+		p.advance(true)
+	}
+}
+
+// State 当前状态
+func (p *PomodoroTimer) State() PomodoroState {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.state.Phase != PhaseIdle && !p.state.Paused {
+		p.update()
+	}
+	return p.state
+}
+
+// update 按时钟扣减剩余时间
+func (p *PomodoroTimer) update() {
+	now := p.now()
+	elapsed := now.Sub(p.lastTick)
+	if elapsed < 0 {
+		elapsed = 0
+	}
+	p.lastTick = now
+	p.spent += elapsed
+	p.state.Remaining -= elapsed
+}
+
+// enter 进入一个阶段，从完整时长开始倒计时
+func (p *PomodoroTimer) enter(phase string) {
+	now := p.now()
+	p.state.Phase = phase
+	p.state.Remaining = phaseLength(phase)
+	p.state.Paused = false
+	p.phaseStart = now
+	p.lastTick = now
+	p.spent = 0
+}
+
+// advance 进入下一阶段，completed 表示当前阶段是正常走完的
+func (p *PomodoroTimer) advance(completed bool) {
+	var next string
+	switch p.state.Phase {
+	case PhaseWork:
+		next = PhaseShortBreak
+		if completed {
+			p.recordPomodoro()
+			p.state.Cycles++
+			rulesLock.RLock()
+			every := pomodoroLongEvery
+			rulesLock.RUnlock()
+			if every > 0 && p.state.Cycles%every == 0 {
+				next = PhaseLongBreak
+			}
+		}
+	case PhaseLongBreak:
+		p.state.Cycles = 0
+		next = PhaseWork
+	default:
+		next = PhaseWork
+	}
+
+	if completed {
+		p.notify(next)
+	}
+	p.enter(next)
+}
+
+// recordPomodoro 记录刚完成的专注
+func (p *PomodoroTimer) recordPomodoro() {
+	pomodoro := repository.Pomodoro{
+		TaskID:    p.state.TaskID,
+		StartedAt: p.phaseStart,
+		EndedAt:   p.now(),
+		Seconds:   int(p.spent.Seconds()),
+	}
+	if _, err := p.app.DB.InsertPomodoro(pomodoro); err != nil {
+		p.app.ErrorLog.Println(err)
+	}
+	if pomodoro.TaskID != 0 && p.app.TasksTable != nil {
+		p.app.refreshTasksTable()
+	}
+}
+
+// notify 阶段切换提醒
+func (p *PomodoroTimer) notify(next string) {
+	rulesLock.RLock()
+	notify := notifyPomodoro
+	rulesLock.RUnlock()
+	if !notify {
+		return
+	}
+
+	content := fmt.Sprintf("休息结束，开始专注 %d 分钟", int(phaseLength(PhaseWork).Minutes()))
+	if next != PhaseWork {
+		content = fmt.Sprintf("完成第 %d 个番茄，%s %d 分钟", p.state.Cycles, phaseText[next], int(phaseLength(next).Minutes()))
+	}
+	p.app.notify(repository.NotificationPomodoro, "番茄钟", content)
+}
+
+// pomodoroBar 总览下方的番茄钟倒计时和控制按钮
+func (app *Config) pomodoroBar() *fyne.Container {
+	app.PomodoroText = canvas.NewText("", nil)
+	app.PomodoroText.TextSize = 18
+
+	start := widget.NewButtonWithIcon("开始", theme.MediaPlayIcon(), func() {
+		app.Pomodoro.Start(currentActiveTask())
+		app.refreshPomodoro()
+	})
+	app.PomodoroPause = widget.NewButtonWithIcon("暂停", theme.MediaPauseIcon(), func() {
+		if app.Pomodoro.State().Paused {
+			app.Pomodoro.Resume()
+		} else {
+			app.Pomodoro.Pause()
+		}
+		app.refreshPomodoro()
+	})
+	skip := widget.NewButtonWithIcon("跳过", theme.MediaSkipNextIcon(), func() {
+		app.Pomodoro.Skip()
+		app.refreshPomodoro()
+	})
+	stop := widget.NewButtonWithIcon("停止", theme.MediaStopIcon(), func() {
+		app.Pomodoro.Stop()
+		app.refreshPomodoro()
+	})
+
+	app.refreshPomodoro()
+
+	return container.NewBorder(nil, nil, app.PomodoroText, container.NewHBox(start, app.PomodoroPause, skip, stop))
+}
+
+// refreshPomodoro 刷新番茄钟倒计时
+func (app *Config) refreshPomodoro() {
+	if app.PomodoroText == nil {
+		return
+	}
+
+	state := app.Pomodoro.State()
+	app.PomodoroText.Text = pomodoroText(state)
+	app.PomodoroText.Refresh()
+
+	if state.Paused {
+		app.PomodoroPause.SetText("继续")
+		app.PomodoroPause.SetIcon(theme.MediaPlayIcon())
+	} else {
+		app.PomodoroPause.SetText("暂停")
+		app.PomodoroPause.SetIcon(theme.MediaPauseIcon())
+	}
+}
+
+// pomodoroText 番茄钟状态显示为 专注 24:59 已完成1个
+func pomodoroText(state PomodoroState) string {
+	if state.Phase == PhaseIdle {
+		return phaseText[PhaseIdle]
+	}
+
+	remaining := state.Remaining
+	if remaining < 0 {
+		remaining = 0
+	}
+	seconds := int(remaining.Round(time.Second).Seconds())
+	text := fmt.Sprintf("%s %02d:%02d 已完成%d个", phaseText[state.Phase], seconds/60, seconds%60, state.Cycles)
+	if state.Paused {
+		text += " 已暂停"
+	}
+	return text
+}
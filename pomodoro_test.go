@@ -0,0 +1,100 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+// newTestPomodoro 返回使用假时钟的番茄钟和让时钟前进的函数
+func newTestPomodoro() (*PomodoroTimer, func(d time.Duration)) {
+	clock := time.Date(2023, 1, 2, 10, 0, 0, 0, time.Local)
+	p := NewPomodoroTimer(&testApp)
+	p.now = func() time.Time { return clock }
+
+	advance := func(d time.Duration) {
+		clock = clock.Add(d)
+		p.Tick()
+	}
+	return p, advance
+}
+
+func TestPomodoroTimer_Cycles(t *testing.T) {
+	p, advance := newTestPomodoro()
+	start := time.Date(2023, 1, 2, 0, 0, 0, 0, time.Local)
+	before, _ := testApp.DB.PomodorosBetween(start, start.AddDate(0, 0, 1))
+
+	p.Start(42)
+	if s := p.State(); s.Phase != PhaseWork || s.Remaining != 25*time.Minute {
+		t.Fatal("expected 25 minutes of work, got", s)
+	}
+
+	// 4 个专注，前 3 个后短休息，第 4 个后长休息
+	for i := 1; i <= 4; i++ {
+		advance(25 * time.Minute)
+		s := p.State()
+		if s.Cycles != i {
+			t.Fatalf("expected %d cycles, got %d", i, s.Cycles)
+		}
+		if i < 4 {
+			if s.Phase != PhaseShortBreak {
+				t.Fatal("expected short break, got", s.Phase)
+			}
+			advance(5 * time.Minute)
+			if p.State().Phase != PhaseWork {
+				t.Fatal("expected work after short break, got", p.State().Phase)
+			}
+		}
+	}
+	if s := p.State(); s.Phase != PhaseLongBreak || s.Remaining != 15*time.Minute {
+		t.Fatal("expected long break after 4 pomodoros, got", s)
+	}
+	advance(15 * time.Minute)
+	if s := p.State(); s.Phase != PhaseWork || s.Cycles != 0 {
+		t.Error("expected new round after long break, got", s)
+	}
+
+	after, _ := testApp.DB.PomodorosBetween(start, start.AddDate(0, 0, 1))
+	if len(after)-len(before) != 4 {
+		t.Fatalf("expected 4 pomodoros recorded, got %d", len(after)-len(before))
+	}
+	last := after[len(after)-1]
+	if last.TaskID != 42 || last.Seconds != 25*60 {
+		t.Error("wrong pomodoro recorded:", last)
+	}
+}
+
+func TestPomodoroTimer_PauseSkipStop(t *testing.T) {
+	p, advance := newTestPomodoro()
+
+	p.Start(0)
+	advance(10 * time.Minute)
+	p.Pause()
+	advance(time.Hour)
+	if s := p.State(); !s.Paused || s.Remaining != 15*time.Minute {
+		t.Fatal("paused timer should not count down, got", s)
+	}
+	p.Resume()
+	advance(5 * time.Minute)
+	if s := p.State(); s.Paused || s.Remaining != 10*time.Minute {
+		t.Fatal("resumed timer should count down, got", s)
+	}
+
+	// 跳过的专注不计数
+	p.Skip()
+	if s := p.State(); s.Phase != PhaseShortBreak || s.Cycles != 0 {
+		t.Fatal("expected short break without counting, got", s)
+	}
+	p.Skip()
+	if s := p.State(); s.Phase != PhaseWork || s.Remaining != 25*time.Minute {
+		t.Fatal("expected fresh work phase, got", s)
+	}
+
+	p.Stop()
+	advance(time.Hour)
+	if s := p.State(); s.Phase != PhaseIdle {
+		t.Error("stopped timer should be idle, got", s)
+	}
+	if pomodoroText(p.State()) != "番茄钟未开始" {
+		t.Error("wrong idle text:", pomodoroText(p.State()))
+	}
+}
@@ -2,6 +2,8 @@ package main
 
 import (
 	"NoFish/repository"
+	"errors"
+	"fmt"
 	"strconv"
 
 	"fyne.io/fyne/v2"
@@ -60,7 +62,7 @@ func (app *Config) getPrizesTable() *widget.Table {
 			}
 		})
 
-	colWidths := []float32{50, 200, 200, 200, 110}
+	colWidths := []float32{50, 250, 100, 100, 110, 110}
 	for i := 0; i < len(colWidths); i++ {
 		t.SetColumnWidth(i, colWidths[i])
 	}
@@ -69,7 +71,38 @@ func (app *Config) getPrizesTable() *widget.Table {
 
 // exchangePrize  兑换奖品
 func (app *Config) exchangePrize(row int) {
+	id, _ := strconv.Atoi(app.Prizes[row][0].(string))
+	desc := app.Prizes[row][1].(string)
+	points, _ := strconv.Atoi(app.Prizes[row][2].(string))
+
+	balance := app.currentBalance()
+	if balance < points {
+		dialog.ShowError(insufficientPointsError(points, balance), app.MainWindow)
+		return
+	}
+
+	dialog.ShowConfirm("兑换奖品", fmt.Sprintf("确定花费 %d 积分兑换「%s」？", points, desc), func(ok bool) {
+		if !ok {
+			return
+		}
+		_, err := app.DB.RedeemPrize(int64(id))
+		if errors.Is(err, repository.ErrInsufficientPoints) {
+			dialog.ShowError(insufficientPointsError(points, app.currentBalance()), app.MainWindow)
+			return
+		}
+		if err != nil {
+			dialog.ShowError(err, app.MainWindow)
+			app.ErrorLog.Println(err)
+			return
+		}
+
+		app.refreshPrizesTable()
+		app.refreshSum()
+	}, app.MainWindow)
+}
 
+func insufficientPointsError(points, balance int) error {
+	return fmt.Errorf("积分不足：兑换需要 %d 积分，当前只有 %d 积分", points, balance)
 }
 
 // getPrizeSlice 从数据库中获取奖品信息
@@ -82,7 +115,7 @@ func (app *Config) getPrizeSlice() [][]interface{} {
 		app.ErrorLog.Println(err)
 	}
 
-	slice = append(slice, []interface{}{"ID", "描述", "积分", "是否重复", "删除?"})
+	slice = append(slice, []interface{}{"ID", "描述", "积分", "是否重复", "兑换", "删除?"})
 
 	for _, x := range prizes {
 
@@ -96,6 +129,7 @@ func (app *Config) getPrizeSlice() [][]interface{} {
 		case 0:
 			currentRow = append(currentRow, "否")
 		}
+		currentRow = append(currentRow, widget.NewButtonWithIcon("兑换", theme.ContentAddIcon(), func() {}))
 		currentRow = append(currentRow, widget.NewButtonWithIcon("删除", theme.DeleteIcon(), func() {}))
 
 		slice = append(slice, currentRow)
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"github.com/go-vgo/robotgo"
+	"sync"
+)
+
+// WindowProbe 读取当前活动窗口的信息
+type WindowProbe interface {
+	ActiveTitle() string
+	// ActiveApp 应用/进程名
+	ActiveApp() string
+	ActivePid() int
+}
+
+// URLProbe 能读取浏览器当前网址的 WindowProbe 可以额外实现
+type URLProbe interface {
+	ActiveURL(app string) string
+}
+
+// robotgoProbe 通过 robotgo 读取桌面上的活动窗口
+type robotgoProbe struct{}
+
+func (robotgoProbe) ActiveTitle() string {
+	return robotgo.GetTitle()
+}
+
+func (robotgoProbe) ActiveApp() string {
+	name, err := robotgo.FindName(robotgo.GetPid())
+	if err != nil {
+		return ""
+	}
+	return name
+}
+
+func (robotgoProbe) ActivePid() int {
+	return robotgo.GetPid()
+}
+
+func (robotgoProbe) ActiveURL(app string) string {
+	return activeBrowserURL(app)
+}
+
+// Window 一个预设的窗口
+type Window struct {
+	Title string
+	App   string
+	Pid   int
+	URL   string
+}
+
+// ScriptedProbe 按顺序返回预设的窗口，每读取一次标题切换到下一个窗口，
+// 最后一个窗口会一直保持，用于测试和没有桌面环境的情况
+type ScriptedProbe struct {
+	mu      sync.Mutex
+	windows []Window
+	current Window
+}
+
+// NewScriptedProbe 返回依次切换到 windows 的 probe
+func NewScriptedProbe(windows ...Window) *ScriptedProbe {
+	return &ScriptedProbe{windows: windows}
+}
+
+func (p *ScriptedProbe) ActiveTitle() string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if len(p.windows) > 0 {
+		p.current = p.windows[0]
+		p.windows = p.windows[1:]
+	}
+	return p.current.Title
+}
+
+func (p *ScriptedProbe) ActiveApp() string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.current.App
+}
+
+func (p *ScriptedProbe) ActivePid() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.current.Pid
+}
+
+func (p *ScriptedProbe) ActiveURL(app string) string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.current.URL
+}
@@ -0,0 +1,299 @@
+package main
+
+import (
+	"NoFish/repository"
+	"fmt"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 稍后提醒推迟的时长
+var reminderSnooze = 10 * time.Minute
+
+// ReminderSchedule 提醒的时间规则
+type ReminderSchedule interface {
+	// Next after 之后的下一次提醒时间，没有下一次返回零值
+	Next(after time.Time) time.Time
+}
+
+// parseReminderSpec 按提醒方式解析 Spec
+func parseReminderSpec(kind, spec string) (ReminderSchedule, error) {
+	switch kind {
+	case repository.ReminderInterval:
+		minutes, err := strconv.Atoi(strings.TrimSpace(spec))
+		if err != nil || minutes <= 0 {
+			return nil, fmt.Errorf("间隔应为正整数分钟: %q", spec)
+		}
+		return intervalSchedule(time.Duration(minutes) * time.Minute), nil
+	case repository.ReminderFixed:
+		var minutes fixedSchedule
+		for _, part := range strings.Split(spec, ",") {
+			m, err := parseMinute(strings.TrimSpace(part))
+			if err != nil {
+				return nil, err
+			}
+			minutes = append(minutes, m)
+		}
+		sort.Ints(minutes)
+		return minutes, nil
+	case repository.ReminderCron:
+		return parseCron(spec)
+	}
+	return nil, fmt.Errorf("提醒方式未知: %s", kind)
+}
+
+// intervalSchedule 每隔一段时间
+type intervalSchedule time.Duration
+
+func (s intervalSchedule) Next(after time.Time) time.Time {
+	return after.Add(time.Duration(s))
+}
+
+// fixedSchedule 每天固定的几个时间，从零点开始的分钟数，从小到大
+type fixedSchedule []int
+
+func (s fixedSchedule) Next(after time.Time) time.Time {
+	day := time.Date(after.Year(), after.Month(), after.Day(), 0, 0, 0, 0, after.Location())
+	for i := 0; i < 2; i++ {
+		for _, m := range s {
+			t := day.Add(time.Duration(m) * time.Minute)
+			if t.After(after) {
+				return t
+			}
+		}
+		day = day.AddDate(0, 0, 1)
+	}
+	return time.Time{}
+}
+
+// cronSchedule 5 段的 cron 表达式，每段是允许取值的位图
+type cronSchedule struct {
+	minute, hour, dom, month, dow uint64
+	// 日和星期都指定时，满足其一即可
+	domAny, dowAny bool
+}
+
+// parseCron 解析 分 时 日 月 星期，支持 *、列表、范围和步长，星期 0 和 7 都是周日
+func parseCron(spec string) (*cronSchedule, error) {
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("cron 表达式应为 5 段: %q", spec)
+	}
+
+	var c cronSchedule
+	var err error
+	bounds := []struct {
+		bits     *uint64
+		min, max int
+	}{
+		{&c.minute, 0, 59},
+		{&c.hour, 0, 23},
+		{&c.dom, 1, 31},
+		{&c.month, 1, 12},
+		{&c.dow, 0, 7},
+	}
+	for i, b := range bounds {
+		if *b.bits, err = parseCronField(fields[i], b.min, b.max); err != nil {
+			return nil, fmt.Errorf("cron 表达式 %q 第 %d 段错误: %w", spec, i+1, err)
+		}
+	}
+	if c.dow&(1<<7) != 0 {
+		c.dow |= 1
+	}
+	c.domAny = fields[2] == "*"
+	c.dowAny = fields[4] == "*"
+
+	return &c, nil
+}
+
+func parseCronField(field string, min, max int) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(field, ",") {
+		step := 1
+		hasStep := false
+		if i := strings.Index(part, "/"); i >= 0 {
+			s, err := strconv.Atoi(part[i+1:])
+			if err != nil || s <= 0 {
+				return 0, fmt.Errorf("步长错误: %q", part)
+			}
+			step, hasStep, part = s, true, part[:i]
+		}
+
+		lo, hi := min, max
+		switch {
+		case part == "*":
+		case strings.Contains(part, "-"):
+			bounds := strings.SplitN(part, "-", 2)
+			var err1, err2 error
+			lo, err1 = strconv.Atoi(bounds[0])
+			hi, err2 = strconv.Atoi(bounds[1])
+			if err1 != nil || err2 != nil {
+				return 0, fmt.Errorf("范围错误: %q", part)
+			}
+		default:
+			v, err := strconv.Atoi(part)
+			if err != nil {
+				return 0, fmt.Errorf("数字错误: %q", part)
+			}
+			lo = v
+			if !hasStep {
+				hi = v
+			}
+		}
+		if lo < min || hi > max || lo > hi {
+			return 0, fmt.Errorf("超出范围 %d-%d: %q", min, max, part)
+		}
+		for v := lo; v <= hi; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+	return bits, nil
+}
+
+func (c *cronSchedule) Next(after time.Time) time.Time {
+	loc := after.Location()
+	t := time.Date(after.Year(), after.Month(), after.Day(), after.Hour(), after.Minute()+1, 0, 0, loc)
+
+	// 最多找 5 年，不存在的日期（比如 2 月 30 日）返回零值
+	for limit := t.AddDate(5, 0, 0); t.Before(limit); {
+		if c.month&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc)
+			continue
+		}
+		if !c.dayMatches(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+			continue
+		}
+		if c.hour&(1<<uint(t.Hour())) == 0 {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, loc)
+			continue
+		}
+		if c.minute&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
+
+func (c *cronSchedule) dayMatches(t time.Time) bool {
+	dom := c.dom&(1<<uint(t.Day())) != 0
+	dow := c.dow&(1<<uint(t.Weekday())) != 0
+	if c.domAny || c.dowAny {
+		return dom && dow
+	}
+	return dom || dow
+}
+
+// ReminderScheduler 按规则发出用户自定义的提醒，离开电脑时不提醒，
+// 设置了只在工作时间提醒的，工作时间外也不提醒
+type ReminderScheduler struct {
+	app  *Config
+	idle IdleProbe
+	// 时钟，测试时可以替换
+	now func() time.Time
+
+	// 启动时间，之前错过的提醒不再补发
+	started time.Time
+}
+
+// NewReminderScheduler 使用 idle 判断是否离开的提醒调度
+func NewReminderScheduler(app *Config, idle IdleProbe) *ReminderScheduler {
+	return &ReminderScheduler{
+		app:  app,
+		idle: idle,
+		now:  time.Now,
+	}
+}
+
+// Run 定时检查提醒
+func (s *ReminderScheduler) Run() {
+	for {
+		s.Check()
+		time.Sleep(currentSampleInterval())
+	}
+}
+
+// Check 发出所有到时间的提醒，返回发出的提醒
+func (s *ReminderScheduler) Check() []repository.Reminder {
+	now := s.now()
+	if s.started.IsZero() {
+		s.started = now
+	}
+
+	reminders, err := s.app.DB.AllReminders()
+	if err != nil {
+		s.app.ErrorLog.Println(err)
+		return nil
+	}
+
+	var fired []repository.Reminder
+	for _, r := range reminders {
+		if !r.Enabled || !s.due(r, now) {
+			continue
+		}
+
+		// 到时间了但人不在或者不在工作时间，这一次跳过，下一次从现在开始算
+		suppressed := isIdle(s.idle) || (r.WorkHoursOnly && !isInWorkTime(now))
+		if err := s.app.DB.MarkReminder(r.ID, now, time.Time{}); err != nil {
+			s.app.ErrorLog.Println(err)
+			continue
+		}
+		if suppressed {
+			continue
+		}
+
+		r.LastFiredAt = now
+		r.SnoozedUntil = time.Time{}
+		s.app.showReminder(r)
+		fired = append(fired, r)
+	}
+
+	return fired
+}
+
+// due 提醒是否到时间：推迟过的到推迟时间提醒，否则按规则从上一次提醒开始算
+func (s *ReminderScheduler) due(r repository.Reminder, now time.Time) bool {
+	if !r.SnoozedUntil.IsZero() {
+		return !now.Before(r.SnoozedUntil)
+	}
+
+	schedule, err := parseReminderSpec(r.Kind, r.Spec)
+	if err != nil {
+		s.app.ErrorLog.Println(err)
+		return false
+	}
+	base := r.LastFiredAt
+	if base.Before(s.started) {
+		base = s.started
+	}
+	next := schedule.Next(base)
+	return !next.IsZero() && !now.Before(next)
+}
+
+// showReminder 发出通知，并在窗口里弹出可以稍后提醒的对话框
+func (app *Config) showReminder(r repository.Reminder) {
+	n := app.notify(repository.NotificationReminder, r.Title, r.Message)
+
+	content := widget.NewLabel(r.Message)
+	dialog.ShowCustomConfirm(r.Title, "知道了", fmt.Sprintf("%d分钟后提醒", int(reminderSnooze.Minutes())), content, func(dismiss bool) {
+		if dismiss {
+			app.dismissNotification(n.ID)
+			return
+		}
+		app.snoozeReminder(r)
+	}, app.MainWindow)
+}
+
+// snoozeReminder 稍后再提醒一次
+func (app *Config) snoozeReminder(r repository.Reminder) {
+	err := app.DB.MarkReminder(r.ID, r.LastFiredAt, time.Now().Add(reminderSnooze))
+	if err != nil {
+		app.ErrorLog.Println(err)
+	}
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"NoFish/repository"
+	"testing"
+	"time"
+)
+
+func TestParseReminderSpec(t *testing.T) {
+	at := func(s string) time.Time {
+		tm, _ := time.ParseInLocation("2006-01-02 15:04", s, time.Local)
+		return tm
+	}
+
+	cases := []struct {
+		kind, spec string
+		after      string
+		next       string
+	}{
+		{repository.ReminderInterval, "45", "2023-01-02 10:00", "2023-01-02 10:45"},
+		{repository.ReminderFixed, "15:30, 10:00", "2023-01-02 10:00", "2023-01-02 15:30"},
+		{repository.ReminderFixed, "10:00,15:30", "2023-01-02 16:00", "2023-01-03 10:00"},
+		// 工作日每两小时整点
+		{repository.ReminderCron, "0 */2 * * 1-5", "2023-01-02 10:00", "2023-01-02 12:00"},
+		{repository.ReminderCron, "0 */2 * * 1-5", "2023-01-06 23:00", "2023-01-09 00:00"},
+		// 每周五 17:30，星期 7 也是周日
+		{repository.ReminderCron, "30 17 * * 5", "2023-01-02 10:00", "2023-01-06 17:30"},
+		{repository.ReminderCron, "0 9 * * 7", "2023-01-02 10:00", "2023-01-08 09:00"},
+		// 日和星期都指定时满足其一即可
+		{repository.ReminderCron, "0 9 15 * 1", "2023-01-10 10:00", "2023-01-15 09:00"},
+		{repository.ReminderCron, "0,30 9 1 2 *", "2023-01-02 10:00", "2023-02-01 09:00"},
+	}
+	for _, c := range cases {
+		s, err := parseReminderSpec(c.kind, c.spec)
+		if err != nil {
+			t.Errorf("%s %q: %s", c.kind, c.spec, err)
+			continue
+		}
+		if next := s.Next(at(c.after)); !next.Equal(at(c.next)) {
+			t.Errorf("%s %q after %s: expected %s, got %s", c.kind, c.spec, c.after, c.next, next.Format("2006-01-02 15:04"))
+		}
+	}
+
+	invalid := []struct{ kind, spec string }{
+		{repository.ReminderInterval, "0"},
+		{repository.ReminderFixed, "25:00"},
+		{repository.ReminderCron, "* * * *"},
+		{repository.ReminderCron, "60 * * * *"},
+		{repository.ReminderCron, "*/0 * * * *"},
+		{"weekly", "1"},
+	}
+	for _, c := range invalid {
+		if _, err := parseReminderSpec(c.kind, c.spec); err == nil {
+			t.Errorf("%s %q should be invalid", c.kind, c.spec)
+		}
+	}
+
+	// 不存在的日期没有下一次
+	s, _ := parseReminderSpec(repository.ReminderCron, "0 9 30 2 *")
+	if next := s.Next(at("2023-01-02 10:00")); !next.IsZero() {
+		t.Error("expected no next time for february 30th, got", next)
+	}
+}
+
+func TestReminderScheduler_Check(t *testing.T) {
+	app := testApp
+	app.DB = repository.NewTestRepository()
+	_ = app.DB.Migrate()
+	testApp.loadFishRules()
+
+	// 周一上班时间，只保留一个 30 分钟的提醒
+	all, _ := app.DB.AllReminders()
+	for _, r := range all {
+		_ = app.DB.DeleteReminder(r.ID)
+	}
+	r, _ := app.DB.InsertReminder(repository.Reminder{Title: "喝水", Kind: repository.ReminderInterval, Spec: "30", Enabled: true, WorkHoursOnly: true})
+
+	clock := time.Date(2023, 1, 2, 10, 0, 0, 0, time.Local)
+	var idle time.Duration
+	s := NewReminderScheduler(&app, IdleFunc(func() time.Duration { return idle }))
+	s.now = func() time.Time { return clock }
+
+	check := func(d time.Duration) int {
+		clock = clock.Add(d)
+		return len(s.Check())
+	}
+
+	if n := check(0); n != 0 {
+		t.Fatal("fired on start")
+	}
+	if n := check(29 * time.Minute); n != 0 {
+		t.Fatal("fired before interval")
+	}
+	if n := check(time.Minute); n != 1 {
+		t.Fatal("expected reminder after 30 minutes, got", n)
+	}
+
+	// 离开电脑时跳过，从跳过时重新计时
+	idle = 10 * time.Minute
+	if n := check(30 * time.Minute); n != 0 {
+		t.Fatal("fired while idle")
+	}
+	idle = 0
+	if n := check(20 * time.Minute); n != 0 {
+		t.Fatal("fired too early after skipped reminder")
+	}
+	if n := check(10 * time.Minute); n != 1 {
+		t.Fatal("expected reminder 30 minutes after skipped one, got", n)
+	}
+
+	// 稍后提醒
+	_ = app.DB.MarkReminder(r.ID, clock, clock.Add(reminderSnooze))
+	if n := check(5 * time.Minute); n != 0 {
+		t.Fatal("fired before snooze ends")
+	}
+	if n := check(5 * time.Minute); n != 1 {
+		t.Fatal("expected snoozed reminder, got", n)
+	}
+	all, _ = app.DB.AllReminders()
+	if !all[0].SnoozedUntil.IsZero() || !all[0].LastFiredAt.Equal(clock) {
+		t.Error("snooze not cleared after firing:", all[0])
+	}
+
+	// 下班后不提醒
+	clock = time.Date(2023, 1, 2, 19, 0, 0, 0, time.Local)
+	if n := check(0); n != 0 {
+		t.Error("fired outside work hours")
+	}
+}
@@ -0,0 +1,520 @@
+package main
+
+import (
+	"NoFish/repository"
+	"bytes"
+	"fmt"
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"html/template"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// 报告类型
+const (
+	ReportDaily  = "daily"
+	ReportWeekly = "weekly"
+)
+
+var reportKindText = map[string]string{
+	ReportDaily:  "日报",
+	ReportWeekly: "周报",
+}
+
+// 报告里列出摸鱼最多的窗口个数
+var reportTopTitles = 5
+
+// Report 一天或一周的工作报告，时间范围是 [From, To)
+type Report struct {
+	Kind string
+	From time.Time
+	To   time.Time
+
+	TasksCompleted []repository.Task
+	FishCount      int
+	// 获得和花掉（兑换、扣分）的积分，都是正数
+	PointsEarned int
+	PointsSpent  int
+	// 工作和摸鱼窗口的时长，单位秒
+	ProductiveSeconds  int64
+	DistractingSeconds int64
+	// 专注和番茄钟
+	FocusSeconds  int64
+	FocusSessions int
+	Pomodoros     int
+	// 摸鱼时长最多的窗口标题
+	TopDistracting []repository.ActivityTotal
+
+	// 周报对比的上一周，日报为 nil
+	Previous *Report
+}
+
+// reportRange now 所在的一天或一周（从周一开始）
+func reportRange(kind string, now time.Time) (time.Time, time.Time) {
+	day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	if kind == ReportWeekly {
+		monday := day.AddDate(0, 0, -(int(day.Weekday())+6)%7)
+		return monday, monday.AddDate(0, 0, 7)
+	}
+	return day, day.AddDate(0, 0, 1)
+}
+
+// buildReport 生成 now 所在这一天或这一周的报告，周报同时生成上一周的用于对比
+func (app *Config) buildReport(kind string, now time.Time) (*Report, error) {
+	from, to := reportRange(kind, now)
+	r, err := app.collectReport(kind, from, to, now)
+	if err != nil {
+		return nil, err
+	}
+	if kind == ReportWeekly {
+		r.Previous, err = app.collectReport(kind, from.AddDate(0, 0, -7), from, now)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return r, nil
+}
+
+// collectReport 从数据库汇总 [from, to) 的数据，now 用于计算还没结束的专注
+func (app *Config) collectReport(kind string, from, to, now time.Time) (*Report, error) {
+	r := &Report{Kind: kind, From: from, To: to}
+
+	tasks, err := app.DB.AllTasks()
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range tasks {
+		if t.Completed && !t.CompletedAt.Before(from) && t.CompletedAt.Before(to) {
+			r.TasksCompleted = append(r.TasksCompleted, t)
+		}
+	}
+
+	summaries, err := app.DB.SummariesBetween(from.Format("2006-01-02"), to.AddDate(0, 0, -1).Format("2006-01-02"))
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range summaries {
+		r.FishCount += int(s.FishCount)
+	}
+
+	entries, err := app.DB.AllLedgerEntries()
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range entries {
+		if e.CreatedAt.Before(from) || !e.CreatedAt.Before(to) {
+			continue
+		}
+		if e.Points > 0 {
+			r.PointsEarned += e.Points
+		} else {
+			r.PointsSpent -= e.Points
+		}
+	}
+
+	activities, err := app.DB.ActivitiesBetween(from, to)
+	if err != nil {
+		return nil, err
+	}
+	titles := map[string]int64{}
+	for _, a := range activities {
+		start, end := a.StartedAt, a.EndedAt
+		if start.Before(from) {
+			start = from
+		}
+		if end.After(to) {
+			end = to
+		}
+		seconds := int64(end.Sub(start).Seconds())
+		switch a.Category {
+		case repository.CategoryProductive:
+			r.ProductiveSeconds += seconds
+		case repository.CategoryDistracting:
+			r.DistractingSeconds += seconds
+			titles[a.Title] += seconds
+		}
+	}
+	for title, seconds := range titles {
+		r.TopDistracting = append(r.TopDistracting, repository.ActivityTotal{Key: title, Seconds: seconds})
+	}
+	sort.Slice(r.TopDistracting, func(i, j int) bool {
+		a, b := r.TopDistracting[i], r.TopDistracting[j]
+		if a.Seconds != b.Seconds {
+			return a.Seconds > b.Seconds
+		}
+		return a.Key < b.Key
+	})
+	if len(r.TopDistracting) > reportTopTitles {
+		r.TopDistracting = r.TopDistracting[:reportTopTitles]
+	}
+
+	sessions, err := app.DB.FocusSessionsBetween(from, to)
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range sessions {
+		end := s.EndedAt
+		if end.IsZero() {
+			end = now
+		}
+		r.FocusSeconds += int64(end.Sub(s.StartedAt).Seconds())
+		r.FocusSessions++
+	}
+
+	pomodoros, err := app.DB.PomodorosBetween(from, to)
+	if err != nil {
+		return nil, err
+	}
+	r.Pomodoros = len(pomodoros)
+
+	return r, nil
+}
+
+// Title 日报 2023-04-12 或 周报 2023-04-10 ~ 2023-04-16
+func (r *Report) Title() string {
+	if r.Kind == ReportWeekly {
+		return fmt.Sprintf("%s %s ~ %s", reportKindText[r.Kind], r.From.Format("2006-01-02"), r.To.AddDate(0, 0, -1).Format("2006-01-02"))
+	}
+	return fmt.Sprintf("%s %s", reportKindText[r.Kind], r.From.Format("2006-01-02"))
+}
+
+// FileName 保存报告的文件名，不含扩展名
+func (r *Report) FileName() string {
+	return r.Kind + "-" + r.From.Format("2006-01-02")
+}
+
+// reportRow 报告概况表的一行，周报带上一周的值和变化
+type reportRow struct {
+	Name     string
+	Value    string
+	Previous string
+	Change   string
+}
+
+// Rows 概况表
+func (r *Report) Rows() []reportRow {
+	count := func(v int64) string { return fmt.Sprintf("%d", v) }
+	duration := func(v int64) string { return spentText(v) }
+
+	metric := func(r *Report) []int64 {
+		return []int64{
+			int64(len(r.TasksCompleted)),
+			int64(r.FishCount),
+			int64(r.PointsEarned),
+			int64(r.PointsSpent),
+			r.ProductiveSeconds,
+			r.DistractingSeconds,
+			r.FocusSeconds,
+			int64(r.FocusSessions),
+			int64(r.Pomodoros),
+		}
+	}
+	names := []string{"完成任务", "摸鱼次数", "获得积分", "花费积分", "工作时长", "摸鱼时长", "专注时长", "专注次数", "番茄钟"}
+	formats := []func(int64) string{count, count, count, count, duration, duration, duration, count, count}
+
+	values := metric(r)
+	var previous []int64
+	if r.Previous != nil {
+		previous = metric(r.Previous)
+	}
+
+	rows := make([]reportRow, len(names))
+	for i, name := range names {
+		rows[i] = reportRow{Name: name, Value: formats[i](values[i])}
+		if previous == nil {
+			continue
+		}
+		rows[i].Previous = formats[i](previous[i])
+		diff := values[i] - previous[i]
+		switch {
+		case diff > 0:
+			rows[i].Change = "+" + formats[i](diff)
+		case diff < 0:
+			rows[i].Change = "-" + formats[i](-diff)
+		default:
+			rows[i].Change = "持平"
+		}
+	}
+	return rows
+}
+
+// Markdown 报告的 Markdown 格式
+func (r *Report) Markdown() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n\n", r.Title())
+
+	if r.Previous != nil {
+		b.WriteString("| 项目 | 本周 | 上周 | 变化 |\n| --- | --- | --- | --- |\n")
+		for _, row := range r.Rows() {
+			fmt.Fprintf(&b, "| %s | %s | %s | %s |\n", row.Name, row.Value, row.Previous, row.Change)
+		}
+	} else {
+		b.WriteString("| 项目 | 数值 |\n| --- | --- |\n")
+		for _, row := range r.Rows() {
+			fmt.Fprintf(&b, "| %s | %s |\n", row.Name, row.Value)
+		}
+	}
+
+	b.WriteString("\n## 完成的任务\n\n")
+	if len(r.TasksCompleted) == 0 {
+		b.WriteString("无\n")
+	}
+	for _, t := range r.TasksCompleted {
+		fmt.Fprintf(&b, "- %s（%d 积分，用时 %s）\n", markdownEscape(t.Name), t.Points, spentText(t.SpentSeconds))
+	}
+
+	b.WriteString("\n## 摸鱼最多的窗口\n\n")
+	if len(r.TopDistracting) == 0 {
+		b.WriteString("无\n")
+	}
+	for i, t := range r.TopDistracting {
+		fmt.Fprintf(&b, "%d. %s %s\n", i+1, markdownEscape(t.Key), spentText(t.Seconds))
+	}
+
+	return b.String()
+}
+
+// markdownEscape 转义窗口标题、任务名里会破坏格式的字符
+func markdownEscape(text string) string {
+	return strings.NewReplacer("\\", "\\\\", "|", "\\|", "*", "\\*", "_", "\\_", "`", "\\`", "[", "\\[", "]", "\\]", "\n", " ").Replace(text)
+}
+
+var reportTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"spent": spentText,
+}).Parse(`<!DOCTYPE html>
+<html lang="zh-CN">
+<head>
+<meta charset="utf-8">
+<title>{{.Report.Title}}</title>
+<style>
+body { font-family: sans-serif; max-width: 720px; margin: 2em auto; color: #333; }
+table { border-collapse: collapse; }
+th, td { border: 1px solid #ddd; padding: 4px 12px; text-align: left; }
+</style>
+</head>
+<body>
+<h1>{{.Report.Title}}</h1>
+<table>
+{{- if .Report.Previous}}
+<tr><th>项目</th><th>本周</th><th>上周</th><th>变化</th></tr>
+{{- range .Rows}}
+<tr><td>{{.Name}}</td><td>{{.Value}}</td><td>{{.Previous}}</td><td>{{.Change}}</td></tr>
+{{- end}}
+{{- else}}
+<tr><th>项目</th><th>数值</th></tr>
+{{- range .Rows}}
+<tr><td>{{.Name}}</td><td>{{.Value}}</td></tr>
+{{- end}}
+{{- end}}
+</table>
+<h2>完成的任务</h2>
+<ul>
+{{- range .Report.TasksCompleted}}
+<li>{{.Name}}（{{.Points}} 积分，用时 {{spent .SpentSeconds}}）</li>
+{{- else}}
+<li>无</li>
+{{- end}}
+</ul>
+<h2>摸鱼最多的窗口</h2>
+<ol>
+{{- range .Report.TopDistracting}}
+<li>{{.Key}} {{spent .Seconds}}</li>
+{{- else}}
+<li>无</li>
+{{- end}}
+</ol>
+</body>
+</html>
+`))
+
+// HTML 报告的 HTML 格式
+func (r *Report) HTML() (string, error) {
+	var b bytes.Buffer
+	err := reportTemplate.Execute(&b, struct {
+		Report *Report
+		Rows   []reportRow
+	}{r, r.Rows()})
+	return b.String(), err
+}
+
+// Save 在 dir 下保存 Markdown 和 HTML 两个文件，返回 Markdown 文件的路径
+func (r *Report) Save(dir string) (string, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+	html, err := r.HTML()
+	if err != nil {
+		return "", err
+	}
+
+	base := filepath.Join(dir, r.FileName())
+	if err := os.WriteFile(base+".html", []byte(html), 0644); err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(base+".md", []byte(r.Markdown()), 0644); err != nil {
+		return "", err
+	}
+	return base + ".md", nil
+}
+
+// reportDir 报告保存在数据目录的 reports 文件夹
+func (app *Config) reportDir() string {
+	return filepath.Join(app.App.Storage().RootURI().Path(), "reports")
+}
+
+// ReportScheduler 每天最后一段工作时间结束后自动生成日报，
+// 这一周之后没有工作日时同时生成周报
+type ReportScheduler struct {
+	app *Config
+	// 时钟，测试时可以替换
+	now func() time.Time
+	// 报告保存的文件夹
+	dir string
+}
+
+// NewReportScheduler 报告保存在 dir 的自动生成
+func NewReportScheduler(app *Config, dir string) *ReportScheduler {
+	return &ReportScheduler{
+		app: app,
+		now: time.Now,
+		dir: dir,
+	}
+}
+
+// Run 定时检查是否到了生成报告的时间
+func (s *ReportScheduler) Run() {
+	for {
+		s.Check()
+		time.Sleep(currentSampleInterval())
+	}
+}
+
+// Check 下班后生成当天还没生成的报告，返回生成的报告
+func (s *ReportScheduler) Check() []*Report {
+	now := s.now()
+	rulesLock.RLock()
+	schedule := workSchedule
+	rulesLock.RUnlock()
+
+	end := schedule.WorkEnd(now)
+	if end.IsZero() || now.Before(end) {
+		return nil
+	}
+
+	settings, err := s.app.DB.AllSettings()
+	if err != nil {
+		s.app.ErrorLog.Println(err)
+		return nil
+	}
+
+	var reports []*Report
+	today := now.Format("2006-01-02")
+	if settings[settingLastDailyReport] != today {
+		if r := s.generate(ReportDaily, now, settingLastDailyReport, today); r != nil {
+			reports = append(reports, r)
+		}
+	}
+
+	// 本周后面几天都不用上班，这一天是本周最后一个工作日
+	from, to := reportRange(ReportWeekly, now)
+	week := from.Format("2006-01-02")
+	if settings[settingLastWeeklyReport] != week && !workLeftInWeek(schedule, now, to) {
+		if r := s.generate(ReportWeekly, now, settingLastWeeklyReport, week); r != nil {
+			reports = append(reports, r)
+		}
+	}
+	return reports
+}
+
+// generate 生成并保存报告，记下已经生成过
+func (s *ReportScheduler) generate(kind string, now time.Time, key, value string) *Report {
+	r, err := s.app.buildReport(kind, now)
+	if err != nil {
+		s.app.ErrorLog.Println(err)
+		return nil
+	}
+	path, err := r.Save(s.dir)
+	if err != nil {
+		s.app.ErrorLog.Println(err)
+		return nil
+	}
+	if err := s.app.DB.SaveSetting(key, value); err != nil {
+		s.app.ErrorLog.Println(err)
+	}
+	s.app.notify(repository.NotificationReport, r.Title(), "已保存到 "+path)
+	return r
+}
+
+// workLeftInWeek now 之后到 weekEnd 之前的日子里还有没有工作时间
+func workLeftInWeek(schedule *Schedule, now, weekEnd time.Time) bool {
+	day := time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, now.Location())
+	for ; day.Before(weekEnd); day = day.AddDate(0, 0, 1) {
+		if !schedule.WorkEnd(day).IsZero() {
+			return true
+		}
+	}
+	return false
+}
+
+// reportMenu 菜单里的报告
+func (app *Config) reportMenu() *fyne.Menu {
+	return fyne.NewMenu("报告",
+		fyne.NewMenuItem("今日日报", func() {
+			app.reportDialog(ReportDaily)
+		}),
+		fyne.NewMenuItem("本周周报", func() {
+			app.reportDialog(ReportWeekly)
+		}),
+	)
+}
+
+// reportDialog 预览报告，可以保存或复制为 Markdown、HTML
+func (app *Config) reportDialog(kind string) {
+	r, err := app.buildReport(kind, time.Now())
+	if err != nil {
+		dialog.ShowError(err, app.MainWindow)
+		app.ErrorLog.Println(err)
+		return
+	}
+	markdown := r.Markdown()
+
+	preview := widget.NewMultiLineEntry()
+	preview.SetText(markdown)
+	preview.Wrapping = fyne.TextWrapWord
+
+	saveButton := widget.NewButtonWithIcon("保存", theme.DocumentSaveIcon(), func() {
+		path, err := r.Save(app.reportDir())
+		if err != nil {
+			dialog.ShowError(err, app.MainWindow)
+			app.ErrorLog.Println(err)
+			return
+		}
+		dialog.ShowInformation("报告已保存", path, app.MainWindow)
+	})
+	copyMarkdown := widget.NewButtonWithIcon("复制 Markdown", theme.ContentCopyIcon(), func() {
+		app.MainWindow.Clipboard().SetContent(markdown)
+	})
+	copyHTML := widget.NewButtonWithIcon("复制 HTML", theme.ContentCopyIcon(), func() {
+		html, err := r.HTML()
+		if err != nil {
+			dialog.ShowError(err, app.MainWindow)
+			app.ErrorLog.Println(err)
+			return
+		}
+		app.MainWindow.Clipboard().SetContent(html)
+	})
+
+	buttons := container.NewHBox(saveButton, copyMarkdown, copyHTML)
+	content := container.NewBorder(nil, buttons, nil, nil, preview)
+
+	reportDialog := dialog.NewCustom(r.Title(), "关闭", content, app.MainWindow)
+	reportDialog.Resize(fyne.Size{Width: 600, Height: 500})
+	reportDialog.Show()
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"NoFish/repository"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// seedReport 2023-06-14（周三）和上一周的数据，返回清理函数
+func seedReport(t *testing.T) func() {
+	restoreBalance(t)
+	day := time.Date(2023, 6, 14, 0, 0, 0, 0, time.Local)
+
+	task, _ := testApp.DB.InsertTask(repository.Task{Name: "写周报 <b>", DueDate: day, Priority: 2, Points: 3, Completed: true, CompletedAt: day.Add(16 * time.Hour), SpentSeconds: 4800})
+	_ = testApp.DB.IncrementSummary("2023-06-14", 2, 1, 0)
+	_ = testApp.DB.IncrementSummary("2023-06-07", 5, 0, 0)
+	_, _ = testApp.DB.RecordActivity(repository.Activity{StartedAt: day.Add(9 * time.Hour), EndedAt: day.Add(11 * time.Hour), Title: "微信读书", Category: repository.CategoryProductive, Samples: 1})
+	_, _ = testApp.DB.RecordActivity(repository.Activity{StartedAt: day.Add(11 * time.Hour), EndedAt: day.Add(11*time.Hour + 30*time.Minute), Title: "知乎 | 首页", Category: repository.CategoryDistracting, Samples: 1})
+	_, _ = testApp.DB.RecordActivity(repository.Activity{StartedAt: day.Add(14 * time.Hour), EndedAt: day.Add(14*time.Hour + 10*time.Minute), Title: "即刻", Category: repository.CategoryDistracting, Samples: 1})
+	_, _ = testApp.DB.InsertLedgerEntry(repository.LedgerEntry{Kind: repository.LedgerEarned, Points: 3, CreatedAt: day.Add(16 * time.Hour)})
+	_, _ = testApp.DB.InsertFocusSession(repository.FocusSession{StartedAt: day.Add(9 * time.Hour), PlannedMinutes: 50, EndedAt: day.Add(9*time.Hour + 50*time.Minute), Status: repository.FocusCompleted})
+
+	return func() {
+		_ = testApp.DB.DeleteTask(task.ID)
+	}
+}
+
+func TestApp_buildReport(t *testing.T) {
+	defer seedReport(t)()
+	now := time.Date(2023, 6, 14, 18, 30, 0, 0, time.Local)
+
+	daily, err := testApp.buildReport(ReportDaily, now)
+	if err != nil {
+		t.Fatal("build daily report failed:", err)
+	}
+	if daily.Title() != "日报 2023-06-14" || daily.Previous != nil {
+		t.Error("wrong daily report:", daily.Title(), daily.Previous)
+	}
+	if len(daily.TasksCompleted) != 1 || daily.FishCount != 2 || daily.PointsEarned != 3 {
+		t.Error("wrong daily totals:", daily)
+	}
+	if daily.ProductiveSeconds != 7200 || daily.DistractingSeconds != 2400 || daily.FocusSeconds != 3000 || daily.FocusSessions != 1 {
+		t.Error("wrong daily time:", daily)
+	}
+	if len(daily.TopDistracting) != 2 || daily.TopDistracting[0].Key != "知乎 | 首页" || daily.TopDistracting[0].Seconds != 1800 {
+		t.Error("wrong top distracting titles:", daily.TopDistracting)
+	}
+
+	md := daily.Markdown()
+	for _, want := range []string{"# 日报 2023-06-14", "| 摸鱼次数 | 2 |", "| 工作时长 | 2:00 |", "- 写周报 <b>（3 积分，用时 1:20）", "1. 知乎 \\| 首页 0:30"} {
+		if !strings.Contains(md, want) {
+			t.Errorf("markdown missing %q:\n%s", want, md)
+		}
+	}
+	html, err := daily.HTML()
+	if err != nil {
+		t.Fatal("render html failed:", err)
+	}
+	if !strings.Contains(html, "<title>日报 2023-06-14</title>") || !strings.Contains(html, "写周报 &lt;b&gt;") {
+		t.Error("wrong html:", html)
+	}
+
+	weekly, _ := testApp.buildReport(ReportWeekly, now)
+	if weekly.Title() != "周报 2023-06-12 ~ 2023-06-18" || weekly.Previous == nil {
+		t.Fatal("wrong weekly report:", weekly.Title())
+	}
+	rows := weekly.Rows()
+	if rows[1].Name != "摸鱼次数" || rows[1].Value != "2" || rows[1].Previous != "5" || rows[1].Change != "-3" {
+		t.Error("weekly report not compared with previous week:", rows[1])
+	}
+	if rows[4].Change != "+2:00" {
+		t.Error("wrong duration change:", rows[4])
+	}
+	if !strings.Contains(weekly.Markdown(), "| 项目 | 本周 | 上周 | 变化 |") {
+		t.Error("weekly markdown without comparison:", weekly.Markdown())
+	}
+}
+
+func TestReportScheduler_Check(t *testing.T) {
+	defer seedReport(t)()
+	defer func() {
+		_ = testApp.DB.SaveSetting(settingLastDailyReport, "")
+		_ = testApp.DB.SaveSetting(settingLastWeeklyReport, "")
+	}()
+
+	dir := t.TempDir()
+	s := NewReportScheduler(&testApp, dir)
+	clock := time.Date(2023, 6, 14, 17, 59, 0, 0, time.Local)
+	s.now = func() time.Time { return clock }
+
+	// 默认周一到周五 9:30 - 18:00，下班前不生成
+	if reports := s.Check(); len(reports) != 0 {
+		t.Fatal("report generated before work ends:", reports)
+	}
+
+	clock = clock.Add(time.Minute)
+	reports := s.Check()
+	if len(reports) != 1 || reports[0].Kind != ReportDaily {
+		t.Fatal("expected daily report after work, got", reports)
+	}
+	for _, name := range []string{"daily-2023-06-14.md", "daily-2023-06-14.html"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Error("report not saved:", err)
+		}
+	}
+	sent := testNotifier.Notifications()
+	if len(sent) == 0 || sent[len(sent)-1].Kind != repository.NotificationReport {
+		t.Error("report not notified:", sent)
+	}
+
+	// 同一天只生成一次
+	if reports := s.Check(); len(reports) != 0 {
+		t.Error("daily report generated twice:", reports)
+	}
+
+	// 周五下班后同时生成周报
+	clock = time.Date(2023, 6, 16, 18, 30, 0, 0, time.Local)
+	reports = s.Check()
+	if len(reports) != 2 || reports[1].Kind != ReportWeekly {
+		t.Fatal("expected daily and weekly report on friday, got", reports)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "weekly-2023-06-12.html")); err != nil {
+		t.Error("weekly report not saved:", err)
+	}
+}
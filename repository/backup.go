@@ -0,0 +1,299 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+)
+
+// BackupVersion 导出文件的格式版本，格式有不兼容的修改时加一
+const BackupVersion = 1
+
+// 导入方式
+const (
+	// ImportMerge 合并到现有数据，已存在的记录跳过
+	ImportMerge = "merge"
+	// ImportReplace 清空现有数据后导入
+	ImportReplace = "replace"
+)
+
+// SettingActiveTask 当前任务的设置项，替换导入时清除
+const SettingActiveTask = "active_task"
+
+var (
+	// ErrBackupVersion 导出文件的格式或数据库版本比当前程序新，无法导入
+	ErrBackupVersion = errors.New("unsupported backup version")
+	// ErrInvalidBackup 导出文件里的数据不完整或有冲突
+	ErrInvalidBackup     = errors.New("invalid backup")
+	errUnknownImportMode = errors.New("unknown import mode")
+)
+
+// Backup 导出的全部数据，用于换电脑时迁移和分享奖品列表
+type Backup struct {
+	Version int `json:"version"`
+	// 导出时数据库的版本
+	SchemaVersion int           `json:"schema_version"`
+	ExportedAt    time.Time     `json:"exported_at"`
+	Tasks         []Task        `json:"tasks"`
+	Prizes        []Prize       `json:"prizes"`
+	Ledger        []LedgerEntry `json:"ledger"`
+	Summaries     []Summary     `json:"summaries"`
+	Rules         []Rule        `json:"rules"`
+	Activity      []Activity    `json:"activity"`
+}
+
+// latestSchemaVersion 当前程序的数据库版本
+func latestSchemaVersion() int {
+	return migrations[len(migrations)-1].version
+}
+
+// NewBackup 导出仓库里的任务、奖品、积分流水、每日概况、规则和活动记录
+func NewBackup(repo Repository, now time.Time) (*Backup, error) {
+	b := &Backup{
+		Version:       BackupVersion,
+		SchemaVersion: latestSchemaVersion(),
+		ExportedAt:    now,
+	}
+
+	var err error
+	if b.Tasks, err = repo.AllTasks(); err != nil {
+		return nil, err
+	}
+	if b.Prizes, err = repo.AllPrizes(); err != nil {
+		return nil, err
+	}
+	if b.Ledger, err = repo.AllLedgerEntries(); err != nil {
+		return nil, err
+	}
+	if b.Summaries, err = repo.SummariesBetween("0000-01-01", "9999-12-31"); err != nil {
+		return nil, err
+	}
+	if b.Rules, err = repo.AllRules(); err != nil {
+		return nil, err
+	}
+	if b.Activity, err = repo.ActivitiesBetween(time.Unix(0, 0), time.Unix(math.MaxInt64, 0)); err != nil {
+		return nil, err
+	}
+
+	return b, nil
+}
+
+// Validate 检查版本，以及每种记录的 id 都大于 0 且不重复
+func (b *Backup) Validate() error {
+	if b.Version < 1 || b.Version > BackupVersion {
+		return fmt.Errorf("%w: format %d", ErrBackupVersion, b.Version)
+	}
+	if b.SchemaVersion > latestSchemaVersion() {
+		return fmt.Errorf("%w: schema %d", ErrBackupVersion, b.SchemaVersion)
+	}
+
+	checks := []struct {
+		name string
+		ids  []int64
+	}{
+		{"tasks", taskIDs(b.Tasks)},
+		{"prizes", prizeIDs(b.Prizes)},
+		{"ledger", ledgerIDs(b.Ledger)},
+		{"rules", ruleIDs(b.Rules)},
+		{"activity", activityIDs(b.Activity)},
+	}
+	for _, c := range checks {
+		seen := map[int64]bool{}
+		for _, id := range c.ids {
+			if id <= 0 || seen[id] {
+				return fmt.Errorf("%w: %s id %d", ErrInvalidBackup, c.name, id)
+			}
+			seen[id] = true
+		}
+	}
+
+	days := map[string]bool{}
+	for _, s := range b.Summaries {
+		if _, err := time.Parse("2006-01-02", s.Day); err != nil || days[s.Day] {
+			return fmt.Errorf("%w: summary day %q", ErrInvalidBackup, s.Day)
+		}
+		days[s.Day] = true
+	}
+	for _, r := range b.Rules {
+		if r.Kind != RuleAllow && r.Kind != RuleBlock {
+			return fmt.Errorf("%w: rule %d kind %q", ErrInvalidBackup, r.ID, r.Kind)
+		}
+	}
+	for _, a := range b.Activity {
+		switch a.Category {
+		case CategoryProductive, CategoryNeutral, CategoryDistracting, CategoryIdle:
+		default:
+			return fmt.Errorf("%w: activity %d category %q", ErrInvalidBackup, a.ID, a.Category)
+		}
+	}
+
+	return nil
+}
+
+// importPlan 导入时要写入的记录，以及导入文件里的 id 到本地 id 的对应关系。
+// 合并时已存在的记录不再写入，引用它的记录指向本地那一条；
+// 引用的记录不在导入文件里时 id 对应为 0
+type importPlan struct {
+	Backup
+	// 清空后导入时保留原来的 id
+	keepIDs  bool
+	taskIDs  map[int64]int64
+	prizeIDs map[int64]int64
+	ruleIDs  map[int64]int64
+}
+
+// newImportPlan 根据导入方式和本地已有的数据决定要写入哪些记录，
+// local 只在合并时使用
+func newImportPlan(b *Backup, mode string, local *Backup) (*importPlan, error) {
+	if mode != ImportMerge && mode != ImportReplace {
+		return nil, errUnknownImportMode
+	}
+	if err := b.Validate(); err != nil {
+		return nil, err
+	}
+
+	p := &importPlan{
+		keepIDs:  mode == ImportReplace,
+		taskIDs:  map[int64]int64{},
+		prizeIDs: map[int64]int64{},
+		ruleIDs:  map[int64]int64{},
+	}
+	if mode == ImportReplace {
+		p.Backup = *b
+		return p, nil
+	}
+
+	existingTasks := map[string]int64{}
+	for _, t := range local.Tasks {
+		existingTasks[taskKey(t)] = t.ID
+	}
+	for _, t := range b.Tasks {
+		if id, ok := existingTasks[taskKey(t)]; ok {
+			p.taskIDs[t.ID] = id
+			continue
+		}
+		p.Tasks = append(p.Tasks, t)
+	}
+
+	existingPrizes := map[string]int64{}
+	for _, prize := range local.Prizes {
+		existingPrizes[prize.Description] = prize.ID
+	}
+	for _, prize := range b.Prizes {
+		if id, ok := existingPrizes[prize.Description]; ok {
+			p.prizeIDs[prize.ID] = id
+			continue
+		}
+		p.Prizes = append(p.Prizes, prize)
+	}
+
+	existingRules := map[string]int64{}
+	for _, r := range local.Rules {
+		existingRules[ruleKey(r)] = r.ID
+	}
+	for _, r := range b.Rules {
+		if id, ok := existingRules[ruleKey(r)]; ok {
+			p.ruleIDs[r.ID] = id
+			continue
+		}
+		p.Rules = append(p.Rules, r)
+	}
+
+	existingLedger := map[string]bool{}
+	for _, e := range local.Ledger {
+		existingLedger[ledgerKey(e)] = true
+	}
+	for _, e := range b.Ledger {
+		if !existingLedger[ledgerKey(e)] {
+			p.Ledger = append(p.Ledger, e)
+		}
+	}
+
+	// 同一天的概况以本地为准
+	existingDays := map[string]bool{}
+	for _, s := range local.Summaries {
+		existingDays[s.Day] = true
+	}
+	for _, s := range b.Summaries {
+		if !existingDays[s.Day] {
+			p.Summaries = append(p.Summaries, s)
+		}
+	}
+
+	existingActivity := map[string]bool{}
+	for _, a := range local.Activity {
+		existingActivity[activityKey(a)] = true
+	}
+	for _, a := range b.Activity {
+		if !existingActivity[activityKey(a)] {
+			p.Activity = append(p.Activity, a)
+		}
+	}
+
+	return p, nil
+}
+
+// id 导入文件里的记录写入时使用的 id，0 表示自动分配
+func (p *importPlan) id(id int64) int64 {
+	if p.keepIDs {
+		return id
+	}
+	return 0
+}
+
+func taskKey(t Task) string {
+	return fmt.Sprintf("%s|%d", t.Name, t.DueDate.Unix())
+}
+
+func ruleKey(r Rule) string {
+	return fmt.Sprintf("%s|%s|%s|%t", r.Kind, r.MatchType, r.Pattern, r.FocusOnly)
+}
+
+func ledgerKey(e LedgerEntry) string {
+	return fmt.Sprintf("%s|%d|%d|%s", e.Kind, e.Points, e.CreatedAt.Unix(), e.Note)
+}
+
+func activityKey(a Activity) string {
+	return fmt.Sprintf("%d|%s|%s", a.StartedAt.Unix(), a.App, a.Title)
+}
+
+func taskIDs(all []Task) []int64 {
+	var ids []int64
+	for _, t := range all {
+		ids = append(ids, t.ID)
+	}
+	return ids
+}
+
+func prizeIDs(all []Prize) []int64 {
+	var ids []int64
+	for _, p := range all {
+		ids = append(ids, p.ID)
+	}
+	return ids
+}
+
+func ledgerIDs(all []LedgerEntry) []int64 {
+	var ids []int64
+	for _, e := range all {
+		ids = append(ids, e.ID)
+	}
+	return ids
+}
+
+func ruleIDs(all []Rule) []int64 {
+	var ids []int64
+	for _, r := range all {
+		ids = append(ids, r.ID)
+	}
+	return ids
+}
+
+func activityIDs(all []Activity) []int64 {
+	var ids []int64
+	for _, a := range all {
+		ids = append(ids, a.ID)
+	}
+	return ids
+}
@@ -0,0 +1,891 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+)
+
+var _ Repository = (*TestRepository)(nil)
+
+func TestTestRepository_Contract(t *testing.T) {
+	testRepositoryContract(t, func(t *testing.T) Repository {
+		repo := NewTestRepository()
+		if err := repo.Migrate(); err != nil {
+			t.Fatal("migrate failed:", err)
+		}
+		return repo
+	})
+}
+
+// testRepositoryContract Repository 的每个实现都要通过的测试，
+// newRepo 每次返回一个空的、已经 Migrate 过的仓库
+func testRepositoryContract(t *testing.T, newRepo func(t *testing.T) Repository) {
+	t.Run("Tasks", func(t *testing.T) {
+		testTasks(t, newRepo(t))
+	})
+	t.Run("Prizes", func(t *testing.T) {
+		testPrizes(t, newRepo(t))
+	})
+	t.Run("CompleteTask", func(t *testing.T) {
+		testCompleteTask(t, newRepo(t))
+	})
+	t.Run("TaskTime", func(t *testing.T) {
+		testTaskTime(t, newRepo(t))
+	})
+	t.Run("RedeemPrize", func(t *testing.T) {
+		testRedeemPrize(t, newRepo(t))
+	})
+	t.Run("Summary", func(t *testing.T) {
+		testSummary(t, newRepo(t))
+	})
+	t.Run("PointsLedger", func(t *testing.T) {
+		testPointsLedger(t, newRepo(t))
+	})
+	t.Run("Rules", func(t *testing.T) {
+		testRules(t, newRepo(t))
+	})
+	t.Run("Settings", func(t *testing.T) {
+		testSettings(t, newRepo(t))
+	})
+	t.Run("Activity", func(t *testing.T) {
+		testActivity(t, newRepo(t))
+	})
+	t.Run("Schedule", func(t *testing.T) {
+		testSchedule(t, newRepo(t))
+	})
+	t.Run("Pomodoros", func(t *testing.T) {
+		testPomodoros(t, newRepo(t))
+	})
+	t.Run("Reminders", func(t *testing.T) {
+		testReminders(t, newRepo(t))
+	})
+	t.Run("Notifications", func(t *testing.T) {
+		testNotifications(t, newRepo(t))
+	})
+	t.Run("FocusSessions", func(t *testing.T) {
+		testFocusSessions(t, newRepo(t))
+	})
+	t.Run("Backup", func(t *testing.T) {
+		testBackup(t, newRepo(t), newRepo(t))
+	})
+}
+
+func testTasks(t *testing.T, repo Repository) {
+	all, err := repo.AllTasks()
+	if err != nil || len(all) != 0 {
+		t.Fatal("expected no tasks:", all, err)
+	}
+
+	later, err := repo.InsertTask(Task{Name: "学英语", Description: "背单词", DueDate: time.Now().Add(48 * time.Hour), Points: 2, IsLongTerm: 1, Priority: 3})
+	if err != nil {
+		t.Fatal("insert task failed:", err)
+	}
+	sooner, err := repo.InsertTask(Task{Name: "写周报", Description: "本周总结", DueDate: time.Now(), Points: 3, IsLongTerm: 2, Priority: 1})
+	if err != nil {
+		t.Fatal("insert task failed:", err)
+	}
+	if later.ID <= 0 || sooner.ID <= later.ID {
+		t.Error("invalid ids sent back:", later.ID, sooner.ID)
+	}
+
+	all, _ = repo.AllTasks()
+	if len(all) != 2 || all[0].ID != sooner.ID {
+		t.Error("tasks not ordered by due date:", all)
+	}
+
+	fetched, err := repo.GetTaskByID(int(later.ID))
+	if err != nil {
+		t.Fatal("get task failed:", err)
+	}
+	if fetched.Name != "学英语" || fetched.DueDate.Unix() != later.DueDate.Unix() {
+		t.Error("wrong task fetched:", fetched)
+	}
+	if _, err = repo.GetTaskByID(1000); err == nil {
+		t.Error("expected error for missing task")
+	}
+
+	fetched.Priority = 2
+	if err = repo.UpdateTask(later.ID, *fetched); err != nil {
+		t.Error("update task failed:", err)
+	}
+	fetched, _ = repo.GetTaskByID(int(later.ID))
+	if fetched.Priority != 2 {
+		t.Error("update not persisted:", fetched)
+	}
+	if err = repo.UpdateTask(0, *fetched); err == nil {
+		t.Error("expected error when updating id 0")
+	}
+	if err = repo.UpdateTask(1000, *fetched); !errors.Is(err, errUpdateFailed) {
+		t.Error("expected errUpdateFailed for missing task, got", err)
+	}
+
+	if err = repo.DeleteTask(later.ID); err != nil {
+		t.Error("delete task failed:", err)
+	}
+	if err = repo.DeleteTask(later.ID); !errors.Is(err, errUpdateFailed) {
+		t.Error("expected errUpdateFailed for deleted task, got", err)
+	}
+
+	batch, err := repo.InsertTasks([]Task{
+		{Name: "读书", DueDate: time.Now().Add(24 * time.Hour), Points: 1},
+		{Name: "跑步", DueDate: time.Now().Add(72 * time.Hour), Points: 2, Completed: true, CompletedAt: time.Now()},
+	})
+	if err != nil {
+		t.Fatal("insert tasks failed:", err)
+	}
+	if len(batch) != 2 || batch[0].ID <= sooner.ID || batch[1].ID <= batch[0].ID || batch[1].Name != "跑步" {
+		t.Error("invalid tasks sent back:", batch)
+	}
+	all, _ = repo.AllTasks()
+	if len(all) != 3 || all[2].ID != batch[1].ID || !all[2].Completed {
+		t.Error("batch not persisted:", all)
+	}
+}
+
+func testPrizes(t *testing.T, repo Repository) {
+	first, err := repo.InsertPrize(Prize{Description: "奶茶一杯", Points: 10, IsRepeat: 1})
+	if err != nil {
+		t.Fatal("insert prize failed:", err)
+	}
+	second, err := repo.InsertPrize(Prize{Description: "看一场电影", Points: 30, IsRepeat: 0})
+	if err != nil {
+		t.Fatal("insert prize failed:", err)
+	}
+
+	all, err := repo.AllPrizes()
+	if err != nil || len(all) != 2 || all[0].ID != first.ID {
+		t.Error("wrong prizes returned:", all, err)
+	}
+
+	second.Points = 25
+	if err = repo.UpdatePrize(second.ID, *second); err != nil {
+		t.Error("update prize failed:", err)
+	}
+	fetched, err := repo.GetPrizeByID(int(second.ID))
+	if err != nil || fetched.Points != 25 {
+		t.Error("update not persisted:", fetched, err)
+	}
+	if err = repo.UpdatePrize(1000, *second); !errors.Is(err, errUpdateFailed) {
+		t.Error("expected errUpdateFailed for missing prize, got", err)
+	}
+
+	if err = repo.DeletePrize(first.ID); err != nil {
+		t.Error("delete prize failed:", err)
+	}
+	if err = repo.DeletePrize(first.ID); !errors.Is(err, errUpdateFailed) {
+		t.Error("expected errUpdateFailed for deleted prize, got", err)
+	}
+
+	batch, err := repo.InsertPrizes([]Prize{{Description: "买本书", Points: 20}, {Description: "睡个懒觉", Points: 15, IsRepeat: 1}})
+	if err != nil {
+		t.Fatal("insert prizes failed:", err)
+	}
+	if len(batch) != 2 || batch[0].ID <= second.ID || batch[1].ID <= batch[0].ID {
+		t.Error("invalid prizes sent back:", batch)
+	}
+	all, _ = repo.AllPrizes()
+	if len(all) != 3 || all[2].Description != "睡个懒觉" || all[2].IsRepeat != 1 {
+		t.Error("batch not persisted:", all)
+	}
+}
+
+func testCompleteTask(t *testing.T, repo Repository) {
+	task, err := repo.InsertTask(Task{Name: "写周报", Description: "本周总结", DueDate: time.Now(), Points: 3, IsLongTerm: 2, Priority: 1})
+	if err != nil {
+		t.Fatal("insert task failed:", err)
+	}
+
+	// 今天已经有概况时在原来的行上累加
+	today := time.Now().Format("2006-01-02")
+	if err = repo.IncrementSummary(today, 2, 0, 0); err != nil {
+		t.Fatal("increment summary failed:", err)
+	}
+
+	done, err := repo.CompleteTask(task.ID)
+	if err != nil {
+		t.Fatal("complete task failed:", err)
+	}
+	if !done.Completed || done.CompletedAt.IsZero() {
+		t.Error("task not marked completed:", done)
+	}
+	if s, _ := repo.GetSummaryByDay(today); s.FinishCount != 1 || s.FishCount != 2 {
+		t.Error("today's finish count not incremented:", s)
+	}
+
+	balance, _ := repo.PointsBalance()
+	if balance != 3 {
+		t.Errorf("points not credited: expected 3, got %d", balance)
+	}
+
+	s, _ := repo.GetSummaryByDay(done.CompletedAt.Format("2006-01-02"))
+	if s.FinishCount != 1 || s.PrizeCount != 3 {
+		t.Error("completion not written to summary:", s)
+	}
+
+	if _, err = repo.CompleteTask(task.ID); !errors.Is(err, ErrTaskCompleted) {
+		t.Error("expected ErrTaskCompleted, got", err)
+	}
+
+	fetched, err := repo.GetTaskByID(int(task.ID))
+	if err != nil {
+		t.Fatal("get task failed:", err)
+	}
+	if !fetched.Completed || fetched.CompletedAt.Unix() != done.CompletedAt.Unix() {
+		t.Error("completion not persisted:", fetched)
+	}
+}
+
+func testTaskTime(t *testing.T, repo Repository) {
+	task, err := repo.InsertTask(Task{Name: "写周报", DueDate: time.Now(), Points: 3, EstimateMinutes: 90})
+	if err != nil {
+		t.Fatal("insert task failed:", err)
+	}
+
+	if err = repo.AddTaskTime(task.ID, 600); err != nil {
+		t.Fatal("add task time failed:", err)
+	}
+	_ = repo.AddTaskTime(task.ID, 20)
+
+	fetched, _ := repo.GetTaskByID(int(task.ID))
+	if fetched.EstimateMinutes != 90 || fetched.SpentSeconds != 620 {
+		t.Error("wrong estimate or spent time:", fetched)
+	}
+
+	// 完成后保留用时
+	_, _ = repo.CompleteTask(task.ID)
+	all, _ := repo.AllTasks()
+	if len(all) != 1 || all[0].SpentSeconds != 620 || all[0].EstimateMinutes != 90 {
+		t.Error("spent time lost after completing:", all)
+	}
+
+	if err = repo.AddTaskTime(1000, 20); !errors.Is(err, errUpdateFailed) {
+		t.Error("expected errUpdateFailed for missing task, got", err)
+	}
+}
+
+func testRedeemPrize(t *testing.T, repo Repository) {
+	if _, err := repo.InsertLedgerEntry(LedgerEntry{Kind: LedgerAdjusted, Points: 8, Note: "初始积分"}); err != nil {
+		t.Fatal("insert ledger entry failed:", err)
+	}
+	once, err := repo.InsertPrize(Prize{Description: "看一场电影", Points: 5, IsRepeat: 0})
+	if err != nil {
+		t.Fatal("insert prize failed:", err)
+	}
+	repeat, err := repo.InsertPrize(Prize{Description: "奶茶一杯", Points: 2, IsRepeat: 1})
+	if err != nil {
+		t.Fatal("insert prize failed:", err)
+	}
+	tooExpensive, err := repo.InsertPrize(Prize{Description: "买新键盘", Points: 500, IsRepeat: 1})
+	if err != nil {
+		t.Fatal("insert prize failed:", err)
+	}
+
+	if _, err = repo.RedeemPrize(tooExpensive.ID); !errors.Is(err, ErrInsufficientPoints) {
+		t.Error("expected ErrInsufficientPoints, got", err)
+	}
+
+	r, err := repo.RedeemPrize(once.ID)
+	if err != nil {
+		t.Fatal("redeem failed:", err)
+	}
+	if r.Points != 5 || r.PrizeID != once.ID || r.Description != "看一场电影" {
+		t.Error("wrong redemption sent back:", r)
+	}
+	if _, err = repo.RedeemPrize(repeat.ID); err != nil {
+		t.Fatal("redeem failed:", err)
+	}
+
+	balance, _ := repo.PointsBalance()
+	if balance != 1 {
+		t.Errorf("balance not debited: expected 1, got %d", balance)
+	}
+
+	// 不可重复兑换的奖品兑换后移除，可重复的保留
+	if _, err = repo.GetPrizeByID(int(once.ID)); err == nil {
+		t.Error("non repeatable prize still exists after redemption")
+	}
+	if _, err = repo.GetPrizeByID(int(repeat.ID)); err != nil {
+		t.Error("repeatable prize removed after redemption:", err)
+	}
+
+	all, err := repo.AllRedemptions()
+	if err != nil || len(all) != 2 || all[0].PrizeID != repeat.ID {
+		t.Error("wrong redemptions returned:", all, err)
+	}
+}
+
+func testSummary(t *testing.T, repo Repository) {
+	day := "2023-01-02"
+	s, err := repo.GetSummaryByDay(day)
+	if err != nil {
+		t.Fatal("get empty summary failed:", err)
+	}
+	if s.ID != 0 || s.FishCount != 0 || s.Day != day {
+		t.Error("expected empty summary, got", s)
+	}
+
+	for i := 0; i < 2; i++ {
+		if err = repo.IncrementSummary(day, 1, 0, 0); err != nil {
+			t.Fatal("increment summary failed:", err)
+		}
+	}
+	if err = repo.IncrementSummary(day, 0, 1, 5); err != nil {
+		t.Fatal("increment summary failed:", err)
+	}
+
+	if err = repo.IncrementSummaryTime(day, CategoryProductive, 40); err != nil {
+		t.Fatal("increment summary time failed:", err)
+	}
+	if err = repo.IncrementSummaryTime(day, CategoryDistracting, 20); err != nil {
+		t.Fatal("increment summary time failed:", err)
+	}
+	if err = repo.IncrementSummaryTime(day, "unknown", 20); !errors.Is(err, errUnknownCategory) {
+		t.Error("expected errUnknownCategory, got", err)
+	}
+
+	s, _ = repo.GetSummaryByDay(day)
+	if s.FishCount != 2 || s.FinishCount != 1 || s.PrizeCount != 5 {
+		t.Error("wrong summary after increments:", s)
+	}
+	if s.ProductiveSeconds != 40 || s.NeutralSeconds != 0 || s.DistractingSeconds != 20 {
+		t.Error("wrong category time after increments:", s)
+	}
+
+	err = repo.UpsertSummary(Summary{Day: "2023-01-03", FishCount: 7})
+	if err != nil {
+		t.Fatal("upsert summary failed:", err)
+	}
+	err = repo.UpsertSummary(Summary{Day: "2023-01-03", FishCount: 8})
+	if err != nil {
+		t.Fatal("upsert summary failed:", err)
+	}
+
+	all, err := repo.SummariesBetween("2023-01-01", "2023-01-03")
+	if err != nil {
+		t.Fatal("get summaries failed:", err)
+	}
+	if len(all) != 2 || all[0].Day != day || all[1].FishCount != 8 {
+		t.Error("wrong summaries returned:", all)
+	}
+}
+
+func testPointsLedger(t *testing.T, repo Repository) {
+	balance, err := repo.PointsBalance()
+	if err != nil || balance != 0 {
+		t.Fatal("expected empty balance:", balance, err)
+	}
+
+	entries := []LedgerEntry{
+		{Kind: LedgerEarned, Points: 10, TaskID: 1, Note: "完成任务"},
+		{Kind: LedgerSpent, Points: -4, PrizeID: 2, Note: "兑换奖品"},
+		{Kind: LedgerAdjusted, Points: 1, Note: "手动调整"},
+	}
+	for _, e := range entries {
+		inserted, err := repo.InsertLedgerEntry(e)
+		if err != nil {
+			t.Fatal("insert ledger entry failed:", err)
+		}
+		if inserted.ID <= 0 || inserted.CreatedAt.IsZero() {
+			t.Error("invalid entry sent back:", inserted)
+		}
+	}
+
+	balance, err = repo.PointsBalance()
+	if err != nil {
+		t.Fatal("get balance failed:", err)
+	}
+	if balance != 7 {
+		t.Errorf("wrong balance: expected 7, got %d", balance)
+	}
+
+	all, err := repo.AllLedgerEntries()
+	if err != nil {
+		t.Fatal("get all ledger entries failed:", err)
+	}
+	if len(all) != 3 {
+		t.Fatalf("wrong number of entries: expected 3, got %d", len(all))
+	}
+	if all[0].TaskID != 1 || all[1].PrizeID != 2 || all[2].TaskID != 0 {
+		t.Error("source ids not round tripped:", all)
+	}
+}
+
+func testRules(t *testing.T, repo Repository) {
+	all, err := repo.AllRules()
+	if err != nil {
+		t.Fatal("get rules failed:", err)
+	}
+	if len(all) != len(defaultRules) || all[0].Pattern != defaultRules[0].Pattern {
+		t.Error("default rules not seeded:", all)
+	}
+
+	r, err := repo.InsertRule(Rule{Kind: RuleBlock, Pattern: "bilibili", Enabled: true})
+	if err != nil {
+		t.Fatal("insert rule failed:", err)
+	}
+	if r.MatchType != MatchTitle {
+		t.Error("match type not defaulted to title:", r.MatchType)
+	}
+
+	r.Enabled = false
+	r.MatchType = MatchURL
+	r.Priority = 5
+	r.FocusOnly = true
+	if err = repo.UpdateRule(r.ID, *r); err != nil {
+		t.Error("update rule failed:", err)
+	}
+	fetched, err := repo.GetRuleByID(int(r.ID))
+	if err != nil || fetched.Enabled || fetched.Kind != RuleBlock || fetched.MatchType != MatchURL || fetched.Priority != 5 || !fetched.FocusOnly {
+		t.Error("update not persisted:", fetched, err)
+	}
+	if err = repo.UpdateRule(1000, *r); !errors.Is(err, errUpdateFailed) {
+		t.Error("expected errUpdateFailed for missing rule, got", err)
+	}
+
+	if err = repo.DeleteRule(r.ID); err != nil {
+		t.Error("delete rule failed:", err)
+	}
+	if err = repo.DeleteRule(r.ID); !errors.Is(err, errUpdateFailed) {
+		t.Error("expected errUpdateFailed for deleted rule, got", err)
+	}
+
+	// 再次 Migrate 不会重复写入默认规则
+	if err = repo.Migrate(); err != nil {
+		t.Fatal("migrate failed:", err)
+	}
+	all, _ = repo.AllRules()
+	if len(all) != len(defaultRules) {
+		t.Errorf("default rules seeded twice: %d rules", len(all))
+	}
+}
+
+func testSettings(t *testing.T, repo Repository) {
+	all, err := repo.AllSettings()
+	if err != nil || len(all) != 0 {
+		t.Fatal("expected no settings:", all, err)
+	}
+
+	if err = repo.SaveSetting("wait_time", "5"); err != nil {
+		t.Fatal("save setting failed:", err)
+	}
+	if err = repo.SaveSetting("wait_time", "3"); err != nil {
+		t.Fatal("save setting failed:", err)
+	}
+
+	all, _ = repo.AllSettings()
+	if len(all) != 1 || all["wait_time"] != "3" {
+		t.Error("wrong settings returned:", all)
+	}
+}
+
+func testActivity(t *testing.T, repo Repository) {
+	start := time.Date(2023, 1, 2, 9, 59, 0, 0, time.Local)
+	record := func(i int, title, app, category string, ruleID int64) *Activity {
+		a, err := repo.RecordActivity(Activity{
+			StartedAt: start.Add(time.Duration(i) * 20 * time.Second),
+			EndedAt:   start.Add(time.Duration(i+1) * 20 * time.Second),
+			Title:     title,
+			App:       app,
+			Category:  category,
+			RuleID:    ruleID,
+		})
+		if err != nil {
+			t.Fatal("record activity failed:", err)
+		}
+		return a
+	}
+
+	// 连续 3 次相同采样合并成一条
+	first := record(0, "Google 搜索", "Safari", CategoryDistracting, 6)
+	for i := 1; i < 3; i++ {
+		merged := record(i, "Google 搜索", "Safari", CategoryDistracting, 6)
+		if merged.ID != first.ID || merged.Samples != i+1 {
+			t.Error("consecutive samples not merged:", merged)
+		}
+	}
+	other := record(3, "main.go", "GoLand", CategoryNeutral, 0)
+	if other.ID == first.ID {
+		t.Error("different sample merged into previous activity")
+	}
+	// 中间断开的相同采样不合并
+	gap := record(5, "main.go", "GoLand", CategoryNeutral, 0)
+	if gap.ID == other.ID {
+		t.Error("non contiguous sample merged into previous activity")
+	}
+
+	all, err := repo.ActivitiesBetween(start, start.Add(time.Hour))
+	if err != nil {
+		t.Fatal("get activities failed:", err)
+	}
+	if len(all) != 3 || all[0].Samples != 3 || all[0].EndedAt.Sub(all[0].StartedAt) != time.Minute || all[0].RuleID != 6 {
+		t.Error("wrong activities returned:", all)
+	}
+
+	byApp, err := repo.TimeByApp(start, start.Add(time.Hour))
+	if err != nil {
+		t.Fatal("get time by app failed:", err)
+	}
+	if len(byApp) != 2 || byApp[0].Key != "Safari" || byApp[0].Seconds != 60 || byApp[1].Seconds != 40 {
+		t.Error("wrong time by app:", byApp)
+	}
+
+	// 跨越区间边界的记录只算区间内的部分
+	byTitle, err := repo.TimeByTitle(start.Add(time.Minute-10*time.Second), start.Add(time.Hour))
+	if err != nil {
+		t.Fatal("get time by title failed:", err)
+	}
+	if len(byTitle) != 2 || byTitle[0].Key != "main.go" || byTitle[0].Seconds != 40 || byTitle[1].Seconds != 10 {
+		t.Error("wrong time by title:", byTitle)
+	}
+}
+
+func testSchedule(t *testing.T, repo Repository) {
+	ranges, err := repo.AllWorkRanges()
+	if err != nil || len(ranges) != 5 {
+		t.Fatal("expected default monday to friday ranges:", ranges, err)
+	}
+	if ranges[0].Weekday != int(time.Monday) || ranges[0].Start != 9*60+30 || ranges[0].End != 18*60 {
+		t.Error("wrong default range:", ranges[0])
+	}
+
+	// 周一加一段更早的时间，排在原来那段前面
+	early, err := repo.InsertWorkRange(WorkRange{Weekday: int(time.Monday), Start: 8 * 60, End: 9 * 60})
+	if err != nil {
+		t.Fatal("insert work range failed:", err)
+	}
+	ranges, _ = repo.AllWorkRanges()
+	if len(ranges) != 6 || ranges[0].ID != early.ID {
+		t.Error("work ranges not ordered by weekday and start:", ranges)
+	}
+	if err = repo.DeleteWorkRange(early.ID); err != nil {
+		t.Error("delete work range failed:", err)
+	}
+	if err = repo.DeleteWorkRange(early.ID); !errors.Is(err, errUpdateFailed) {
+		t.Error("expected errUpdateFailed for missing work range, got", err)
+	}
+
+	late, err := repo.InsertScheduleException(ScheduleException{Day: "2023-01-03", Kind: ExceptionWork, Start: 18 * 60, End: 21 * 60, Note: "加班"})
+	if err != nil {
+		t.Fatal("insert exception failed:", err)
+	}
+	off, _ := repo.InsertScheduleException(ScheduleException{Day: "2023-01-02", Kind: ExceptionOff, Start: 0, End: 24 * 60, Note: "元旦"})
+
+	exceptions, err := repo.AllScheduleExceptions()
+	if err != nil || len(exceptions) != 2 || exceptions[0].ID != off.ID {
+		t.Fatal("exceptions not ordered by day:", exceptions, err)
+	}
+	if exceptions[1].Kind != ExceptionWork || exceptions[1].Note != "加班" || exceptions[1].End != 21*60 {
+		t.Error("wrong exception returned:", exceptions[1])
+	}
+
+	if err = repo.DeleteScheduleException(late.ID); err != nil {
+		t.Error("delete exception failed:", err)
+	}
+	if err = repo.DeleteScheduleException(late.ID); !errors.Is(err, errUpdateFailed) {
+		t.Error("expected errUpdateFailed for missing exception, got", err)
+	}
+}
+
+func testPomodoros(t *testing.T, repo Repository) {
+	day := time.Date(2023, 1, 2, 0, 0, 0, 0, time.Local)
+	record := func(taskID int64, end time.Time) {
+		_, err := repo.InsertPomodoro(Pomodoro{TaskID: taskID, StartedAt: end.Add(-25 * time.Minute), EndedAt: end, Seconds: 25 * 60})
+		if err != nil {
+			t.Fatal("insert pomodoro failed:", err)
+		}
+	}
+	record(1, day.Add(11*time.Hour))
+	record(1, day.Add(10*time.Hour))
+	record(2, day.Add(14*time.Hour))
+	record(0, day.Add(15*time.Hour))
+	record(1, day.Add(34*time.Hour))
+
+	today, err := repo.PomodorosBetween(day, day.AddDate(0, 0, 1))
+	if err != nil {
+		t.Fatal("get pomodoros failed:", err)
+	}
+	if len(today) != 4 || !today[0].EndedAt.Equal(day.Add(10*time.Hour)) {
+		t.Fatal("wrong pomodoros returned:", today)
+	}
+	if today[3].TaskID != 0 || today[0].Seconds != 25*60 || !today[0].StartedAt.Equal(day.Add(10*time.Hour-25*time.Minute)) {
+		t.Error("pomodoro fields not preserved:", today[0], today[3])
+	}
+
+	counts, err := repo.PomodoroCountByTask()
+	if err != nil {
+		t.Fatal("count pomodoros failed:", err)
+	}
+	if len(counts) != 2 || counts[1] != 3 || counts[2] != 1 {
+		t.Error("wrong pomodoro counts:", counts)
+	}
+}
+
+func testReminders(t *testing.T, repo Repository) {
+	all, err := repo.AllReminders()
+	if err != nil || len(all) != len(defaultReminders) {
+		t.Fatal("expected default reminders:", all, err)
+	}
+	// 久坐由休息提醒负责，默认的久坐提醒是关闭的
+	if all[0].Title != "久坐提醒" || all[0].Kind != ReminderInterval || all[0].Enabled || !all[0].LastFiredAt.IsZero() {
+		t.Error("wrong default reminder:", all[0])
+	}
+
+	r, err := repo.InsertReminder(Reminder{Title: "周报", Message: "写周报", Kind: ReminderCron, Spec: "0 17 * * 5", Enabled: true})
+	if err != nil {
+		t.Fatal("insert reminder failed:", err)
+	}
+
+	fired := time.Date(2023, 1, 6, 17, 0, 0, 0, time.Local)
+	if err = repo.MarkReminder(r.ID, fired, fired.Add(10*time.Minute)); err != nil {
+		t.Fatal("mark reminder failed:", err)
+	}
+	all, _ = repo.AllReminders()
+	last := all[len(all)-1]
+	if !last.LastFiredAt.Equal(fired) || !last.SnoozedUntil.Equal(fired.Add(10*time.Minute)) || last.Spec != "0 17 * * 5" {
+		t.Error("reminder not marked:", last)
+	}
+
+	last.Enabled = false
+	last.SnoozedUntil = time.Time{}
+	if err = repo.UpdateReminder(r.ID, last); err != nil {
+		t.Error("update reminder failed:", err)
+	}
+	all, _ = repo.AllReminders()
+	last = all[len(all)-1]
+	if last.Enabled || !last.SnoozedUntil.IsZero() || !last.LastFiredAt.Equal(fired) {
+		t.Error("update not persisted:", last)
+	}
+
+	// 设置对话框里的开关只改这两项，不会覆盖同时写入的提醒时间
+	later := fired.Add(time.Hour)
+	if err = repo.MarkReminder(r.ID, later, later.Add(10*time.Minute)); err != nil {
+		t.Fatal("mark reminder failed:", err)
+	}
+	if err = repo.SetReminderFlags(r.ID, true, true); err != nil {
+		t.Error("set reminder flags failed:", err)
+	}
+	all, _ = repo.AllReminders()
+	last = all[len(all)-1]
+	if !last.Enabled || !last.WorkHoursOnly || !last.LastFiredAt.Equal(later) || !last.SnoozedUntil.Equal(later.Add(10*time.Minute)) {
+		t.Error("flags not set or fire times overwritten:", last)
+	}
+
+	if err = repo.MarkReminder(1000, fired, time.Time{}); !errors.Is(err, errUpdateFailed) {
+		t.Error("expected errUpdateFailed for missing reminder, got", err)
+	}
+	if err = repo.SetReminderFlags(1000, true, false); !errors.Is(err, errUpdateFailed) {
+		t.Error("expected errUpdateFailed for missing reminder, got", err)
+	}
+	if err = repo.DeleteReminder(r.ID); err != nil {
+		t.Error("delete reminder failed:", err)
+	}
+	all, _ = repo.AllReminders()
+	if len(all) != len(defaultReminders) {
+		t.Error("reminder not deleted:", all)
+	}
+}
+
+func testNotifications(t *testing.T, repo Repository) {
+	start := time.Date(2023, 1, 2, 10, 0, 0, 0, time.Local)
+	for i, title := range []string{"摸鱼警告", "休息提醒", "喝水提醒"} {
+		_, err := repo.InsertNotification(Notification{Kind: NotificationFish, Title: title, Content: "内容", CreatedAt: start.Add(time.Duration(i) * time.Minute)})
+		if err != nil {
+			t.Fatal("insert notification failed:", err)
+		}
+	}
+
+	recent, err := repo.RecentNotifications(2)
+	if err != nil {
+		t.Fatal("get notifications failed:", err)
+	}
+	if len(recent) != 2 || recent[0].Title != "喝水提醒" || recent[1].Title != "休息提醒" {
+		t.Fatal("notifications not newest first:", recent)
+	}
+	if !recent[0].DismissedAt.IsZero() || !recent[0].CreatedAt.Equal(start.Add(2*time.Minute)) {
+		t.Error("wrong notification fields:", recent[0])
+	}
+
+	if err = repo.DismissNotification(recent[1].ID, start.Add(time.Hour)); err != nil {
+		t.Fatal("dismiss notification failed:", err)
+	}
+	recent, _ = repo.RecentNotifications(10)
+	if len(recent) != 3 || !recent[1].DismissedAt.Equal(start.Add(time.Hour)) || !recent[0].DismissedAt.IsZero() {
+		t.Error("dismissal not persisted:", recent)
+	}
+	if err = repo.DismissNotification(1000, start); !errors.Is(err, errUpdateFailed) {
+		t.Error("expected errUpdateFailed for missing notification, got", err)
+	}
+}
+
+func testFocusSessions(t *testing.T, repo Repository) {
+	if _, err := repo.ActiveFocusSession(); !errors.Is(err, sql.ErrNoRows) {
+		t.Error("expected sql.ErrNoRows without focus sessions, got", err)
+	}
+
+	start := time.Date(2023, 1, 2, 9, 0, 0, 0, time.Local)
+	first, err := repo.InsertFocusSession(FocusSession{TaskID: 1, StartedAt: start, PlannedMinutes: 50, Status: FocusActive})
+	if err != nil {
+		t.Fatal("insert focus session failed:", err)
+	}
+	second, err := repo.InsertFocusSession(FocusSession{StartedAt: start.Add(2 * time.Hour), PlannedMinutes: 25, Status: FocusActive})
+	if err != nil {
+		t.Fatal("insert focus session failed:", err)
+	}
+
+	active, err := repo.ActiveFocusSession()
+	if err != nil || active.ID != second.ID || !active.EndedAt.IsZero() {
+		t.Fatal("wrong active focus session:", active, err)
+	}
+
+	second.Status = FocusAbandoned
+	second.EndedAt = start.Add(2*time.Hour + 10*time.Minute)
+	second.FishCount = 2
+	second.PenaltyPoints = 5
+	if err = repo.UpdateFocusSession(second.ID, *second); err != nil {
+		t.Fatal("update focus session failed:", err)
+	}
+	active, err = repo.ActiveFocusSession()
+	if err != nil || active.ID != first.ID {
+		t.Error("expected the earlier session to be active:", active, err)
+	}
+	if err = repo.UpdateFocusSession(1000, *second); !errors.Is(err, errUpdateFailed) {
+		t.Error("expected errUpdateFailed for missing focus session, got", err)
+	}
+
+	all, err := repo.FocusSessionsBetween(start, start.AddDate(0, 0, 1))
+	if err != nil || len(all) != 2 {
+		t.Fatal("wrong focus sessions returned:", all, err)
+	}
+	got := all[1]
+	if got.Status != FocusAbandoned || !got.EndedAt.Equal(second.EndedAt) || got.FishCount != 2 || got.PenaltyPoints != 5 || got.TaskID != 0 {
+		t.Error("focus session fields not preserved:", got)
+	}
+	if all[0].TaskID != 1 || all[0].PlannedMinutes != 50 || !all[0].StartedAt.Equal(start) {
+		t.Error("focus session fields not preserved:", all[0])
+	}
+	if later, _ := repo.FocusSessionsBetween(start.Add(time.Hour), start.AddDate(0, 0, 1)); len(later) != 1 {
+		t.Error("expected sessions filtered by start time:", later)
+	}
+}
+
+func testBackup(t *testing.T, src, dst Repository) {
+	day := time.Date(2023, 3, 6, 0, 0, 0, 0, time.Local)
+	task, _ := src.InsertTask(Task{Name: "写报告", DueDate: day, Points: 3, Completed: true, CompletedAt: day.Add(17 * time.Hour)})
+	prize, _ := src.InsertPrize(Prize{Description: "奶茶", Points: 5, IsRepeat: 1})
+	rule, _ := src.InsertRule(Rule{Kind: RuleBlock, MatchType: MatchTitle, Pattern: "微博", Priority: 2, Enabled: true, FocusOnly: true})
+	_, _ = src.InsertLedgerEntry(LedgerEntry{Kind: LedgerEarned, Points: 3, TaskID: task.ID, CreatedAt: day.Add(17 * time.Hour)})
+	_, _ = src.InsertLedgerEntry(LedgerEntry{Kind: LedgerSpent, Points: -5, PrizeID: prize.ID, CreatedAt: day.Add(18 * time.Hour), Note: "兑换"})
+	_ = src.UpsertSummary(Summary{Day: "2023-03-06", FishCount: 2, FinishCount: 1, PrizeCount: 3, ProductiveSeconds: 3600})
+	_, _ = src.RecordActivity(Activity{StartedAt: day.Add(10 * time.Hour), EndedAt: day.Add(10*time.Hour + 5*time.Minute), Title: "微博", App: "chrome", Category: CategoryDistracting, RuleID: rule.ID})
+
+	exported, err := NewBackup(src, day.Add(20*time.Hour))
+	if err != nil {
+		t.Fatal("export failed:", err)
+	}
+	if exported.Version != BackupVersion || len(exported.Tasks) != 1 || len(exported.Prizes) != 1 || len(exported.Ledger) != 2 || len(exported.Summaries) != 1 || len(exported.Activity) != 1 {
+		t.Fatal("wrong backup:", exported)
+	}
+	data, err := json.Marshal(exported)
+	if err != nil {
+		t.Fatal("marshal backup failed:", err)
+	}
+	var b Backup
+	if err = json.Unmarshal(data, &b); err != nil {
+		t.Fatal("unmarshal backup failed:", err)
+	}
+
+	// 合并：同名奖品和相同的规则不重复导入，引用指向本地的记录
+	local, _ := dst.InsertTask(Task{Name: "本地任务", DueDate: day})
+	localPrize, _ := dst.InsertPrize(Prize{Description: "奶茶", Points: 4})
+	for i := 0; i < 2; i++ {
+		if err = dst.Import(&b, ImportMerge); err != nil {
+			t.Fatal("merge failed:", err)
+		}
+	}
+	tasks, _ := dst.AllTasks()
+	prizes, _ := dst.AllPrizes()
+	rules, _ := dst.AllRules()
+	ledger, _ := dst.AllLedgerEntries()
+	if len(tasks) != 2 || len(prizes) != 1 || len(rules) != len(b.Rules) || len(ledger) != 2 {
+		t.Fatal("wrong merged data:", tasks, prizes, rules, ledger)
+	}
+	if ledger[1].PrizeID != localPrize.ID || ledger[0].TaskID == 0 || ledger[0].TaskID == local.ID {
+		t.Error("ledger references not remapped:", ledger)
+	}
+	activity, _ := dst.ActivitiesBetween(day, day.AddDate(0, 0, 1))
+	if len(activity) != 1 || activity[0].RuleID == 0 {
+		t.Fatal("activity not merged:", activity)
+	}
+	if r, err := dst.GetRuleByID(int(activity[0].RuleID)); err != nil || r.Pattern != "微博" || !r.FocusOnly {
+		t.Error("activity rule not remapped:", r, err)
+	}
+	if s, _ := dst.GetSummaryByDay("2023-03-06"); s.FishCount != 2 || s.ProductiveSeconds != 3600 {
+		t.Error("summary not merged:", s)
+	}
+
+	// 格式不对时不写入任何数据
+	newer := b
+	newer.Version = BackupVersion + 1
+	if err = dst.Import(&newer, ImportReplace); !errors.Is(err, ErrBackupVersion) {
+		t.Error("expected ErrBackupVersion, got", err)
+	}
+	duplicated := b
+	duplicated.Tasks = []Task{b.Tasks[0], b.Tasks[0]}
+	if err = dst.Import(&duplicated, ImportReplace); !errors.Is(err, ErrInvalidBackup) {
+		t.Error("expected ErrInvalidBackup for duplicated ids, got", err)
+	}
+	if err = dst.Import(&b, "append"); err == nil {
+		t.Error("expected error for unknown import mode")
+	}
+	if tasks, _ = dst.AllTasks(); len(tasks) != 2 {
+		t.Error("failed import changed data:", tasks)
+	}
+
+	// 引用本地任务和奖品的记录，替换后不能挂到导入的同 id 记录上
+	_, _ = dst.InsertLedgerEntry(LedgerEntry{Kind: LedgerAdjusted, Points: 10, CreatedAt: day})
+	if _, err = dst.RedeemPrize(localPrize.ID); err != nil {
+		t.Fatal("redeem prize failed:", err)
+	}
+	_, _ = dst.InsertPomodoro(Pomodoro{TaskID: local.ID, StartedAt: day.Add(9 * time.Hour), EndedAt: day.Add(9*time.Hour + 25*time.Minute), Seconds: 1500})
+	_, _ = dst.InsertFocusSession(FocusSession{TaskID: local.ID, StartedAt: day.Add(10 * time.Hour), PlannedMinutes: 50, Status: FocusActive})
+	_ = dst.SaveSetting(SettingActiveTask, strconv.FormatInt(local.ID, 10))
+
+	// 替换：本地数据被清空，保留原来的 id
+	if err = dst.Import(&b, ImportReplace); err != nil {
+		t.Fatal("replace failed:", err)
+	}
+	if redemptions, _ := dst.AllRedemptions(); len(redemptions) != 0 {
+		t.Error("redemptions not cleared on replace:", redemptions)
+	}
+	if counts, _ := dst.PomodoroCountByTask(); len(counts) != 0 {
+		t.Error("pomodoros not cleared on replace:", counts)
+	}
+	if active, err := dst.ActiveFocusSession(); !errors.Is(err, sql.ErrNoRows) {
+		t.Error("focus sessions not cleared on replace:", active, err)
+	}
+	if settings, _ := dst.AllSettings(); settings[SettingActiveTask] != "" {
+		t.Error("active task not reset on replace:", settings[SettingActiveTask])
+	}
+	tasks, _ = dst.AllTasks()
+	prizes, _ = dst.AllPrizes()
+	ledger, _ = dst.AllLedgerEntries()
+	if len(tasks) != 1 || tasks[0].ID != task.ID || !tasks[0].CompletedAt.Equal(task.CompletedAt) {
+		t.Error("tasks not replaced:", tasks)
+	}
+	if len(prizes) != 1 || prizes[0].ID != prize.ID || prizes[0].Points != 5 {
+		t.Error("prizes not replaced:", prizes)
+	}
+	if len(ledger) != 2 || ledger[0].TaskID != task.ID || ledger[1].PrizeID != prize.ID || ledger[1].Note != "兑换" {
+		t.Error("ledger not replaced:", ledger)
+	}
+	if balance, _ := dst.PointsBalance(); balance != -2 {
+		t.Error("wrong balance after replace:", balance)
+	}
+	if next, _ := dst.InsertTask(Task{Name: "新任务", DueDate: day}); next.ID <= task.ID {
+		t.Error("id reused after replace:", next.ID)
+	}
+}
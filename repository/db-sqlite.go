@@ -18,90 +18,53 @@ func NewSQLiteRepository(db *sql.DB) *SQLiteRepository {
 	}
 }
 
-// Migrate creates the table(s) we need
-func (repo *SQLiteRepository) Migrate() error {
-	err := createPrize(repo)
+// task 相关方法实现
+const insertTaskStmt = "insert into tasks (name, description, due_date, completed, completed_at, points, is_long_term, priority, estimate_minutes, spent_seconds) values (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)"
+
+func (repo *SQLiteRepository) InsertTask(t Task) (*Task, error) {
+	res, err := repo.Conn.Exec(insertTaskStmt, t.Name, t.Description, t.DueDate.Unix(), t.Completed, nullTime(t.CompletedAt), t.Points, t.IsLongTerm, t.Priority, t.EstimateMinutes, t.SpentSeconds)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	err = createSummary(repo)
+	id, err := res.LastInsertId()
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	return createTask(repo)
-}
-
-func createPrize(repo *SQLiteRepository) error {
-	query := `
-	create table if not exists prizes(
-		id integer primary key autoincrement,
-		description text not null,
-		points int not null,
-		is_repeat int not null
-		);
-	`
-	_, err := repo.Conn.Exec(query)
-	return err
-}
+	t.ID = id
 
-func createTask(repo *SQLiteRepository) error {
-	query := `
-	create table if not exists tasks(
-		id integer primary key autoincrement,
-		name varchar(20) not null,
-		description text not null,
-		due_date int not null,
-		completed int not null,
-		points int not null,
-		is_long_term int not null,
-		priority int not null
-		);
-	`
-	_, err := repo.Conn.Exec(query)
-	if err != nil {
-		return err
-	}
-	return nil
+	return &t, nil
 }
 
-func createSummary(repo *SQLiteRepository) error {
-	query := `
-	create table if not exists summary(
-		id integer primary key autoincrement,
-		fish_count integer not null,
-		finish_count integer not null,
-		prize_count integer not null,
-		day varchar(10) not null
-		);
-	`
-	_, err := repo.Conn.Exec(query)
+// InsertTasks 在一个事务里写入多个任务，有一个失败就都不写入
+func (repo *SQLiteRepository) InsertTasks(tasks []Task) ([]Task, error) {
+	tx, err := repo.Conn.Begin()
 	if err != nil {
-		return err
+		return nil, err
 	}
-	return nil
-}
+	defer tx.Rollback()
 
-// task 相关方法实现
-func (repo *SQLiteRepository) InsertTask(t Task) (*Task, error) {
-	stmt := "insert into tasks (name, description, due_date, completed, points, is_long_term, priority) values (?, ?, ?, ?, ?, ?, ?)"
-	res, err := repo.Conn.Exec(stmt, t.Name, t.Description, t.DueDate.Unix(), t.Completed, t.Points, t.IsLongTerm, t.Priority)
-	if err != nil {
-		return nil, err
+	var inserted []Task
+	for _, t := range tasks {
+		res, err := tx.Exec(insertTaskStmt, t.Name, t.Description, t.DueDate.Unix(), t.Completed, nullTime(t.CompletedAt), t.Points, t.IsLongTerm, t.Priority, t.EstimateMinutes, t.SpentSeconds)
+		if err != nil {
+			return nil, err
+		}
+		if t.ID, err = res.LastInsertId(); err != nil {
+			return nil, err
+		}
+		inserted = append(inserted, t)
 	}
 
-	id, err := res.LastInsertId()
-	if err != nil {
+	if err = tx.Commit(); err != nil {
 		return nil, err
 	}
-
-	t.ID = id
-
-	return &t, nil
+	return inserted, nil
 }
+
 func (repo *SQLiteRepository) AllTasks() ([]Task, error) {
-	query := "select id, name, description, due_date, completed, points, is_long_term, priority from tasks order by due_date"
+	query := "select id, name, description, due_date, completed, completed_at, points, is_long_term, priority, estimate_minutes, spent_seconds from tasks order by due_date"
 	rows, err := repo.Conn.Query(query)
 	if err != nil {
 		return nil, err
@@ -112,45 +75,55 @@ func (repo *SQLiteRepository) AllTasks() ([]Task, error) {
 	for rows.Next() {
 		var t Task
 		var unixTime int64
+		var completedAt sql.NullInt64
 		err := rows.Scan(
 			&t.ID,
 			&t.Name,
 			&t.Description,
 			&unixTime,
 			&t.Completed,
+			&completedAt,
 			&t.Points,
 			&t.IsLongTerm,
 			&t.Priority,
+			&t.EstimateMinutes,
+			&t.SpentSeconds,
 		)
 		if err != nil {
 			return nil, err
 		}
 		t.DueDate = time.Unix(unixTime, 0)
+		t.CompletedAt = timeFromNull(completedAt)
 		all = append(all, t)
 	}
 
 	return all, nil
 }
 func (repo *SQLiteRepository) GetTaskByID(id int) (*Task, error) {
-	row := repo.Conn.QueryRow("select id, name, description, due_date, completed, points, is_long_term, priority from tasks where id = ?", id)
+	row := repo.Conn.QueryRow("select id, name, description, due_date, completed, completed_at, points, is_long_term, priority, estimate_minutes, spent_seconds from tasks where id = ?", id)
 
 	var t Task
 	var unixTime int64
+	var completedAt sql.NullInt64
 	err := row.Scan(
 		&t.ID,
 		&t.Name,
 		&t.Description,
 		&unixTime,
 		&t.Completed,
+		&completedAt,
 		&t.Points,
 		&t.IsLongTerm,
 		&t.Priority,
+		&t.EstimateMinutes,
+		&t.SpentSeconds,
 	)
 
 	if err != nil {
 		return nil, err
 	}
 	t.DueDate = time.Unix(unixTime, 0)
+	t.CompletedAt = timeFromNull(completedAt)
 
 	return &t, nil
 }
@@ -159,20 +132,84 @@ func (repo *SQLiteRepository) UpdateTask(id int64, updated Task) error {
 		return errors.New("id cannot be 0")
 	}
 
-	stmt := "update tasks set name = ?, description = ?, due_date = ?, completed = ?, points = ?, is_long_term = ?, priority = ? where id = ?"
-	res, err := repo.Conn.Exec(stmt, updated.Name, updated.Description, updated.DueDate.Unix(), updated.Completed, updated.Points, updated.IsLongTerm, updated.Priority, id)
+	stmt := "update tasks set name = ?, description = ?, due_date = ?, completed = ?, completed_at = ?, points = ?, is_long_term = ?, priority = ?, estimate_minutes = ?, spent_seconds = ? where id = ?"
+	res, err := repo.Conn.Exec(stmt, updated.Name, updated.Description, updated.DueDate.Unix(), updated.Completed, nullTime(updated.CompletedAt), updated.Points, updated.IsLongTerm, updated.Priority, updated.EstimateMinutes, updated.SpentSeconds, id)
 	return updateCheck(err, res)
 }
 
+// AddTaskTime 累加任务的实际用时
+func (repo *SQLiteRepository) AddTaskTime(id int64, seconds int) error {
+	res, err := repo.Conn.Exec("update tasks set spent_seconds = spent_seconds + ? where id = ?", seconds, id)
+	return updateCheck(err, res)
+}
+
+// CompleteTask 完成任务：标记完成、记录完成时间，并把任务积分记入积分流水
+func (repo *SQLiteRepository) CompleteTask(id int64) (*Task, error) {
+	tx, err := repo.Conn.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var t Task
+	var unixTime int64
+	err = tx.QueryRow("select id, name, description, due_date, completed, points, is_long_term, priority, estimate_minutes, spent_seconds from tasks where id = ?", id).Scan(
+		&t.ID,
+		&t.Name,
+		&t.Description,
+		&unixTime,
+		&t.Completed,
+		&t.Points,
+		&t.IsLongTerm,
+		&t.Priority,
+		&t.EstimateMinutes,
+		&t.SpentSeconds,
+	)
+	if err != nil {
+		return nil, err
+	}
+	t.DueDate = time.Unix(unixTime, 0)
+
+	if t.Completed {
+		return nil, ErrTaskCompleted
+	}
+
+	t.Completed = true
+	t.CompletedAt = time.Now()
+
+	res, err := tx.Exec("update tasks set completed = ?, completed_at = ? where id = ?", t.Completed, t.CompletedAt.Unix(), t.ID)
+	if err = updateCheck(err, res); err != nil {
+		return nil, err
+	}
+
+	stmt := "insert into points_ledger (kind, points, task_id, prize_id, created_at, note) values (?, ?, ?, ?, ?, ?)"
+	_, err = tx.Exec(stmt, LedgerEarned, t.Points, t.ID, nil, t.CompletedAt.Unix(), "完成任务："+t.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = tx.Exec(incrementSummaryStmt, t.CompletedAt.Format("2006-01-02"), 0, 1, t.Points)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return &t, nil
+}
+
 func (repo *SQLiteRepository) DeleteTask(id int64) error {
 	res, err := repo.Conn.Exec("delete from tasks where id = ?", id)
 	return deleteCheck(err, res)
 }
 
 // prize 相关方法实现
+const insertPrizeStmt = "insert into prizes (description, points, is_repeat) values (?, ?, ?)"
+
 func (repo *SQLiteRepository) InsertPrize(p Prize) (*Prize, error) {
-	stmt := "insert into prizes (description, points, is_repeat) values (?, ?, ?)"
-	res, err := repo.Conn.Exec(stmt, p.Description, p.Points, p.IsRepeat)
+	res, err := repo.Conn.Exec(insertPrizeStmt, p.Description, p.Points, p.IsRepeat)
 	if err != nil {
 		return nil, err
 	}
@@ -187,6 +224,32 @@ func (repo *SQLiteRepository) InsertPrize(p Prize) (*Prize, error) {
 	return &p, nil
 }
 
+// InsertPrizes 在一个事务里写入多个奖品，有一个失败就都不写入
+func (repo *SQLiteRepository) InsertPrizes(prizes []Prize) ([]Prize, error) {
+	tx, err := repo.Conn.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var inserted []Prize
+	for _, p := range prizes {
+		res, err := tx.Exec(insertPrizeStmt, p.Description, p.Points, p.IsRepeat)
+		if err != nil {
+			return nil, err
+		}
+		if p.ID, err = res.LastInsertId(); err != nil {
+			return nil, err
+		}
+		inserted = append(inserted, p)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+	return inserted, nil
+}
+
 func (repo *SQLiteRepository) AllPrizes() ([]Prize, error) {
 	query := "select id, description, points, is_repeat from prizes"
 	rows, err := repo.Conn.Query(query)
@@ -247,6 +310,1039 @@ func (repo *SQLiteRepository) DeletePrize(id int64) error {
 	return deleteCheck(err, res)
 }
 
+// RedeemPrize 兑换奖品：在一个事务里检查余额、扣除积分、记录兑换，
+// 不可重复兑换的奖品兑换后从奖品列表中移除
+func (repo *SQLiteRepository) RedeemPrize(id int64) (*Redemption, error) {
+	tx, err := repo.Conn.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var p Prize
+	err = tx.QueryRow("select id, description, points, is_repeat from prizes where id = ?", id).Scan(
+		&p.ID,
+		&p.Description,
+		&p.Points,
+		&p.IsRepeat,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	var balance int
+	err = tx.QueryRow("select coalesce(sum(points), 0) from points_ledger").Scan(&balance)
+	if err != nil {
+		return nil, err
+	}
+	if balance < p.Points {
+		return nil, ErrInsufficientPoints
+	}
+
+	r := Redemption{
+		PrizeID:     p.ID,
+		Description: p.Description,
+		Points:      p.Points,
+		RedeemedAt:  time.Now(),
+	}
+
+	stmt := "insert into points_ledger (kind, points, task_id, prize_id, created_at, note) values (?, ?, ?, ?, ?, ?)"
+	_, err = tx.Exec(stmt, LedgerSpent, -p.Points, nil, p.ID, r.RedeemedAt.Unix(), "兑换奖品："+p.Description)
+	if err != nil {
+		return nil, err
+	}
+
+	stmt = "insert into redemptions (prize_id, description, points, redeemed_at) values (?, ?, ?, ?)"
+	res, err := tx.Exec(stmt, r.PrizeID, r.Description, r.Points, r.RedeemedAt.Unix())
+	if err != nil {
+		return nil, err
+	}
+	r.ID, err = res.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+
+	if p.IsRepeat == 0 {
+		res, err = tx.Exec("delete from prizes where id = ?", p.ID)
+		if err = deleteCheck(err, res); err != nil {
+			return nil, err
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return &r, nil
+}
+
+// AllRedemptions returns all redemptions, newest first
+func (repo *SQLiteRepository) AllRedemptions() ([]Redemption, error) {
+	query := "select id, prize_id, description, points, redeemed_at from redemptions order by redeemed_at desc, id desc"
+	rows, err := repo.Conn.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var all []Redemption
+	for rows.Next() {
+		var r Redemption
+		var unixTime int64
+		err := rows.Scan(
+			&r.ID,
+			&r.PrizeID,
+			&r.Description,
+			&r.Points,
+			&unixTime,
+		)
+		if err != nil {
+			return nil, err
+		}
+		r.RedeemedAt = time.Unix(unixTime, 0)
+		all = append(all, r)
+	}
+
+	return all, nil
+}
+
+// summary 相关方法实现
+const incrementSummaryStmt = `
+	insert into summary (day, fish_count, finish_count, prize_count) values (?, ?, ?, ?)
+	on conflict(day) do update set
+		fish_count = fish_count + excluded.fish_count,
+		finish_count = finish_count + excluded.finish_count,
+		prize_count = prize_count + excluded.prize_count
+	`
+
+// UpsertSummary 写入某一天的概况，已存在则覆盖
+func (repo *SQLiteRepository) UpsertSummary(s Summary) error {
+	stmt := `
+	insert into summary (day, fish_count, finish_count, prize_count, productive_seconds, neutral_seconds, distracting_seconds)
+	values (?, ?, ?, ?, ?, ?, ?)
+	on conflict(day) do update set
+		fish_count = excluded.fish_count,
+		finish_count = excluded.finish_count,
+		prize_count = excluded.prize_count,
+		productive_seconds = excluded.productive_seconds,
+		neutral_seconds = excluded.neutral_seconds,
+		distracting_seconds = excluded.distracting_seconds
+	`
+	_, err := repo.Conn.Exec(stmt, s.Day, s.FishCount, s.FinishCount, s.PrizeCount, s.ProductiveSeconds, s.NeutralSeconds, s.DistractingSeconds)
+	return err
+}
+
+// IncrementSummary 在某一天的概况上累加
+func (repo *SQLiteRepository) IncrementSummary(day string, fish, finish, points int) error {
+	_, err := repo.Conn.Exec(incrementSummaryStmt, day, fish, finish, points)
+	return err
+}
+
+// IncrementSummaryTime 在某一天某个分类的时长上累加
+func (repo *SQLiteRepository) IncrementSummaryTime(day, category string, seconds int) error {
+	var column string
+	switch category {
+	case CategoryProductive:
+		column = "productive_seconds"
+	case CategoryNeutral:
+		column = "neutral_seconds"
+	case CategoryDistracting:
+		column = "distracting_seconds"
+	default:
+		return errUnknownCategory
+	}
+
+	stmt := `
+	insert into summary (day, fish_count, finish_count, prize_count, ` + column + `) values (?, 0, 0, 0, ?)
+	on conflict(day) do update set ` + column + ` = ` + column + ` + excluded.` + column
+	_, err := repo.Conn.Exec(stmt, day, seconds)
+	return err
+}
+
+// GetSummaryByDay 获取某一天的概况，没有记录时返回全 0 的概况
+func (repo *SQLiteRepository) GetSummaryByDay(day string) (*Summary, error) {
+	row := repo.Conn.QueryRow("select id, fish_count, finish_count, prize_count, productive_seconds, neutral_seconds, distracting_seconds, day from summary where day = ?", day)
+
+	var s Summary
+	err := row.Scan(
+		&s.ID,
+		&s.FishCount,
+		&s.FinishCount,
+		&s.PrizeCount,
+		&s.ProductiveSeconds,
+		&s.NeutralSeconds,
+		&s.DistractingSeconds,
+		&s.Day,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return &Summary{Day: day}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &s, nil
+}
+
+// SummariesBetween returns the summaries from day from to day to, both inclusive
+func (repo *SQLiteRepository) SummariesBetween(from, to string) ([]Summary, error) {
+	query := "select id, fish_count, finish_count, prize_count, productive_seconds, neutral_seconds, distracting_seconds, day from summary where day >= ? and day <= ? order by day"
+	rows, err := repo.Conn.Query(query, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var all []Summary
+	for rows.Next() {
+		var s Summary
+		err := rows.Scan(
+			&s.ID,
+			&s.FishCount,
+			&s.FinishCount,
+			&s.PrizeCount,
+			&s.ProductiveSeconds,
+			&s.NeutralSeconds,
+			&s.DistractingSeconds,
+			&s.Day,
+		)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, s)
+	}
+
+	return all, nil
+}
+
+// points ledger 相关方法实现
+func (repo *SQLiteRepository) InsertLedgerEntry(e LedgerEntry) (*LedgerEntry, error) {
+	if e.CreatedAt.IsZero() {
+		e.CreatedAt = time.Now()
+	}
+
+	stmt := "insert into points_ledger (kind, points, task_id, prize_id, created_at, note) values (?, ?, ?, ?, ?, ?)"
+	res, err := repo.Conn.Exec(stmt, e.Kind, e.Points, nullID(e.TaskID), nullID(e.PrizeID), e.CreatedAt.Unix(), e.Note)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+
+	e.ID = id
+
+	return &e, nil
+}
+
+// AllLedgerEntries returns the whole ledger, oldest first
+func (repo *SQLiteRepository) AllLedgerEntries() ([]LedgerEntry, error) {
+	query := "select id, kind, points, task_id, prize_id, created_at, note from points_ledger order by created_at, id"
+	rows, err := repo.Conn.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var all []LedgerEntry
+	for rows.Next() {
+		var e LedgerEntry
+		var taskID, prizeID sql.NullInt64
+		var unixTime int64
+		err := rows.Scan(
+			&e.ID,
+			&e.Kind,
+			&e.Points,
+			&taskID,
+			&prizeID,
+			&unixTime,
+			&e.Note,
+		)
+		if err != nil {
+			return nil, err
+		}
+		e.TaskID = taskID.Int64
+		e.PrizeID = prizeID.Int64
+		e.CreatedAt = time.Unix(unixTime, 0)
+		all = append(all, e)
+	}
+
+	return all, nil
+}
+
+// PointsBalance 当前积分余额，即流水的合计
+func (repo *SQLiteRepository) PointsBalance() (int, error) {
+	var balance int
+	err := repo.Conn.QueryRow("select coalesce(sum(points), 0) from points_ledger").Scan(&balance)
+	if err != nil {
+		return 0, err
+	}
+	return balance, nil
+}
+
+// rule 相关方法实现
+func (repo *SQLiteRepository) InsertRule(r Rule) (*Rule, error) {
+	if r.MatchType == "" {
+		r.MatchType = MatchTitle
+	}
+
+	stmt := "insert into rules (kind, match_type, pattern, priority, enabled, focus_only) values (?, ?, ?, ?, ?, ?)"
+	res, err := repo.Conn.Exec(stmt, r.Kind, r.MatchType, r.Pattern, r.Priority, r.Enabled, r.FocusOnly)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+
+	r.ID = id
+
+	return &r, nil
+}
+
+func (repo *SQLiteRepository) AllRules() ([]Rule, error) {
+	query := "select id, kind, match_type, pattern, priority, enabled, focus_only from rules order by id"
+	rows, err := repo.Conn.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var all []Rule
+	for rows.Next() {
+		var r Rule
+		err := rows.Scan(
+			&r.ID,
+			&r.Kind,
+			&r.MatchType,
+			&r.Pattern,
+			&r.Priority,
+			&r.Enabled,
+			&r.FocusOnly,
+		)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, r)
+	}
+
+	return all, nil
+}
+
+func (repo *SQLiteRepository) GetRuleByID(id int) (*Rule, error) {
+	row := repo.Conn.QueryRow("select id, kind, match_type, pattern, priority, enabled, focus_only from rules where id = ?", id)
+
+	var r Rule
+	err := row.Scan(
+		&r.ID,
+		&r.Kind,
+		&r.MatchType,
+		&r.Pattern,
+		&r.Priority,
+		&r.Enabled,
+		&r.FocusOnly,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &r, nil
+}
+
+func (repo *SQLiteRepository) UpdateRule(id int64, updated Rule) error {
+	if id == 0 {
+		return errors.New("id cannot be 0")
+	}
+
+	if updated.MatchType == "" {
+		updated.MatchType = MatchTitle
+	}
+
+	stmt := "update rules set kind = ?, match_type = ?, pattern = ?, priority = ?, enabled = ?, focus_only = ? where id = ?"
+	res, err := repo.Conn.Exec(stmt, updated.Kind, updated.MatchType, updated.Pattern, updated.Priority, updated.Enabled, updated.FocusOnly, id)
+	return updateCheck(err, res)
+}
+
+func (repo *SQLiteRepository) DeleteRule(id int64) error {
+	res, err := repo.Conn.Exec("delete from rules where id = ?", id)
+	return deleteCheck(err, res)
+}
+
+// setting 相关方法实现
+func (repo *SQLiteRepository) AllSettings() (map[string]string, error) {
+	rows, err := repo.Conn.Query("select key, value from settings")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	all := map[string]string{}
+	for rows.Next() {
+		var key, value string
+		if err := rows.Scan(&key, &value); err != nil {
+			return nil, err
+		}
+		all[key] = value
+	}
+
+	return all, nil
+}
+
+// SaveSetting 保存配置项，已存在则覆盖
+func (repo *SQLiteRepository) SaveSetting(key, value string) error {
+	stmt := "insert into settings (key, value) values (?, ?) on conflict(key) do update set value = excluded.value"
+	_, err := repo.Conn.Exec(stmt, key, value)
+	return err
+}
+
+// activity 相关方法实现
+
+// RecordActivity 记录一次采样，和最近一条记录相同并且时间相连时合并到那一条
+func (repo *SQLiteRepository) RecordActivity(a Activity) (*Activity, error) {
+	if a.Samples == 0 {
+		a.Samples = 1
+	}
+
+	tx, err := repo.Conn.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var last Activity
+	var startedAt, endedAt int64
+	var ruleID sql.NullInt64
+	err = tx.QueryRow("select id, started_at, ended_at, title, app, url, category, rule_id, samples from activity order by id desc limit 1").Scan(
+		&last.ID,
+		&startedAt,
+		&endedAt,
+		&last.Title,
+		&last.App,
+		&last.URL,
+		&last.Category,
+		&ruleID,
+		&last.Samples,
+	)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+	last.StartedAt = time.Unix(startedAt, 0)
+	last.EndedAt = time.Unix(endedAt, 0)
+	last.RuleID = ruleID.Int64
+
+	if err == nil && sameActivity(last, a) {
+		last.EndedAt = time.Unix(a.EndedAt.Unix(), 0)
+		last.Samples += a.Samples
+		res, err := tx.Exec("update activity set ended_at = ?, samples = ? where id = ?", last.EndedAt.Unix(), last.Samples, last.ID)
+		if err = updateCheck(err, res); err != nil {
+			return nil, err
+		}
+		if err = tx.Commit(); err != nil {
+			return nil, err
+		}
+		return &last, nil
+	}
+
+	stmt := "insert into activity (started_at, ended_at, title, app, url, category, rule_id, samples) values (?, ?, ?, ?, ?, ?, ?, ?)"
+	res, err := tx.Exec(stmt, a.StartedAt.Unix(), a.EndedAt.Unix(), a.Title, a.App, a.URL, a.Category, nullID(a.RuleID), a.Samples)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	a.ID = id
+	a.StartedAt = time.Unix(a.StartedAt.Unix(), 0)
+	a.EndedAt = time.Unix(a.EndedAt.Unix(), 0)
+
+	return &a, nil
+}
+
+// ActivitiesBetween returns the activity overlapping [from, to), oldest first
+func (repo *SQLiteRepository) ActivitiesBetween(from, to time.Time) ([]Activity, error) {
+	query := `
+	select id, started_at, ended_at, title, app, url, category, rule_id, samples from activity
+	where ended_at > ? and started_at < ? order by started_at, id
+	`
+	rows, err := repo.Conn.Query(query, from.Unix(), to.Unix())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var all []Activity
+	for rows.Next() {
+		var a Activity
+		var startedAt, endedAt int64
+		var ruleID sql.NullInt64
+		err := rows.Scan(
+			&a.ID,
+			&startedAt,
+			&endedAt,
+			&a.Title,
+			&a.App,
+			&a.URL,
+			&a.Category,
+			&ruleID,
+			&a.Samples,
+		)
+		if err != nil {
+			return nil, err
+		}
+		a.StartedAt = time.Unix(startedAt, 0)
+		a.EndedAt = time.Unix(endedAt, 0)
+		a.RuleID = ruleID.Int64
+		all = append(all, a)
+	}
+
+	return all, nil
+}
+
+// TimeByApp 各应用在 [from, to) 内的使用时长，从多到少
+func (repo *SQLiteRepository) TimeByApp(from, to time.Time) ([]ActivityTotal, error) {
+	return repo.activityTotals("app", from, to)
+}
+
+// TimeByTitle 各窗口标题在 [from, to) 内的使用时长，从多到少
+func (repo *SQLiteRepository) TimeByTitle(from, to time.Time) ([]ActivityTotal, error) {
+	return repo.activityTotals("title", from, to)
+}
+
+// activityTotals 按 column 汇总时长，跨越边界的记录只计算区间内的部分
+func (repo *SQLiteRepository) activityTotals(column string, from, to time.Time) ([]ActivityTotal, error) {
+	query := `
+	select ` + column + `, sum(min(ended_at, ?) - max(started_at, ?)) as seconds from activity
+	where ended_at > ? and started_at < ?
+	group by ` + column + ` order by seconds desc, ` + column
+	rows, err := repo.Conn.Query(query, to.Unix(), from.Unix(), from.Unix(), to.Unix())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var all []ActivityTotal
+	for rows.Next() {
+		var t ActivityTotal
+		if err := rows.Scan(&t.Key, &t.Seconds); err != nil {
+			return nil, err
+		}
+		all = append(all, t)
+	}
+
+	return all, nil
+}
+
+// schedule 相关方法实现
+func (repo *SQLiteRepository) InsertWorkRange(r WorkRange) (*WorkRange, error) {
+	stmt := "insert into work_ranges (weekday, start_minute, end_minute) values (?, ?, ?)"
+	res, err := repo.Conn.Exec(stmt, r.Weekday, r.Start, r.End)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+
+	r.ID = id
+
+	return &r, nil
+}
+
+// AllWorkRanges 按星期和开始时间排序
+func (repo *SQLiteRepository) AllWorkRanges() ([]WorkRange, error) {
+	query := "select id, weekday, start_minute, end_minute from work_ranges order by weekday, start_minute, id"
+	rows, err := repo.Conn.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var all []WorkRange
+	for rows.Next() {
+		var r WorkRange
+		if err := rows.Scan(&r.ID, &r.Weekday, &r.Start, &r.End); err != nil {
+			return nil, err
+		}
+		all = append(all, r)
+	}
+
+	return all, nil
+}
+
+func (repo *SQLiteRepository) DeleteWorkRange(id int64) error {
+	res, err := repo.Conn.Exec("delete from work_ranges where id = ?", id)
+	return deleteCheck(err, res)
+}
+
+func (repo *SQLiteRepository) InsertScheduleException(e ScheduleException) (*ScheduleException, error) {
+	stmt := "insert into schedule_exceptions (day, kind, start_minute, end_minute, note) values (?, ?, ?, ?, ?)"
+	res, err := repo.Conn.Exec(stmt, e.Day, e.Kind, e.Start, e.End, e.Note)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+
+	e.ID = id
+
+	return &e, nil
+}
+
+// AllScheduleExceptions 按日期和开始时间排序
+func (repo *SQLiteRepository) AllScheduleExceptions() ([]ScheduleException, error) {
+	query := "select id, day, kind, start_minute, end_minute, note from schedule_exceptions order by day, start_minute, id"
+	rows, err := repo.Conn.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var all []ScheduleException
+	for rows.Next() {
+		var e ScheduleException
+		if err := rows.Scan(&e.ID, &e.Day, &e.Kind, &e.Start, &e.End, &e.Note); err != nil {
+			return nil, err
+		}
+		all = append(all, e)
+	}
+
+	return all, nil
+}
+
+func (repo *SQLiteRepository) DeleteScheduleException(id int64) error {
+	res, err := repo.Conn.Exec("delete from schedule_exceptions where id = ?", id)
+	return deleteCheck(err, res)
+}
+
+// pomodoro 相关方法实现
+func (repo *SQLiteRepository) InsertPomodoro(p Pomodoro) (*Pomodoro, error) {
+	stmt := "insert into pomodoros (task_id, started_at, ended_at, seconds) values (?, ?, ?, ?)"
+	res, err := repo.Conn.Exec(stmt, nullID(p.TaskID), p.StartedAt.Unix(), p.EndedAt.Unix(), p.Seconds)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+
+	p.ID = id
+
+	return &p, nil
+}
+
+// PomodorosBetween 在 [from, to) 内完成的番茄钟，按完成时间排序
+func (repo *SQLiteRepository) PomodorosBetween(from, to time.Time) ([]Pomodoro, error) {
+	query := `select id, task_id, started_at, ended_at, seconds from pomodoros
+		where ended_at >= ? and ended_at < ? order by ended_at, id`
+	rows, err := repo.Conn.Query(query, from.Unix(), to.Unix())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var all []Pomodoro
+	for rows.Next() {
+		var p Pomodoro
+		var taskID sql.NullInt64
+		var startedAt, endedAt int64
+		if err := rows.Scan(&p.ID, &taskID, &startedAt, &endedAt, &p.Seconds); err != nil {
+			return nil, err
+		}
+		p.TaskID = taskID.Int64
+		p.StartedAt = time.Unix(startedAt, 0)
+		p.EndedAt = time.Unix(endedAt, 0)
+		all = append(all, p)
+	}
+
+	return all, nil
+}
+
+// PomodoroCountByTask 每个任务完成的番茄钟个数
+func (repo *SQLiteRepository) PomodoroCountByTask() (map[int64]int, error) {
+	rows, err := repo.Conn.Query("select task_id, count(*) from pomodoros where task_id is not null group by task_id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := map[int64]int{}
+	for rows.Next() {
+		var taskID int64
+		var count int
+		if err := rows.Scan(&taskID, &count); err != nil {
+			return nil, err
+		}
+		counts[taskID] = count
+	}
+
+	return counts, nil
+}
+
+// reminder 相关方法实现
+func (repo *SQLiteRepository) InsertReminder(r Reminder) (*Reminder, error) {
+	stmt := `insert into reminders (title, message, kind, spec, enabled, work_hours_only, last_fired_at, snoozed_until)
+		values (?, ?, ?, ?, ?, ?, ?, ?)`
+	res, err := repo.Conn.Exec(stmt, r.Title, r.Message, r.Kind, r.Spec, r.Enabled, r.WorkHoursOnly, nullTime(r.LastFiredAt), nullTime(r.SnoozedUntil))
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+
+	r.ID = id
+
+	return &r, nil
+}
+
+func (repo *SQLiteRepository) AllReminders() ([]Reminder, error) {
+	query := "select id, title, message, kind, spec, enabled, work_hours_only, last_fired_at, snoozed_until from reminders order by id"
+	rows, err := repo.Conn.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var all []Reminder
+	for rows.Next() {
+		var r Reminder
+		var firedAt, snoozedUntil sql.NullInt64
+		err := rows.Scan(
+			&r.ID,
+			&r.Title,
+			&r.Message,
+			&r.Kind,
+			&r.Spec,
+			&r.Enabled,
+			&r.WorkHoursOnly,
+			&firedAt,
+			&snoozedUntil,
+		)
+		if err != nil {
+			return nil, err
+		}
+		r.LastFiredAt = timeFromNull(firedAt)
+		r.SnoozedUntil = timeFromNull(snoozedUntil)
+		all = append(all, r)
+	}
+
+	return all, nil
+}
+
+func (repo *SQLiteRepository) UpdateReminder(id int64, updated Reminder) error {
+	if id == 0 {
+		return errors.New("id cannot be 0")
+	}
+
+	stmt := `update reminders set title = ?, message = ?, kind = ?, spec = ?, enabled = ?, work_hours_only = ?,
+		last_fired_at = ?, snoozed_until = ? where id = ?`
+	res, err := repo.Conn.Exec(stmt, updated.Title, updated.Message, updated.Kind, updated.Spec, updated.Enabled, updated.WorkHoursOnly,
+		nullTime(updated.LastFiredAt), nullTime(updated.SnoozedUntil), id)
+	return updateCheck(err, res)
+}
+
+// MarkReminder 只更新提醒时间和稍后提醒时间，不影响用户同时在修改的其他字段
+func (repo *SQLiteRepository) MarkReminder(id int64, firedAt, snoozedUntil time.Time) error {
+	stmt := "update reminders set last_fired_at = ?, snoozed_until = ? where id = ?"
+	res, err := repo.Conn.Exec(stmt, nullTime(firedAt), nullTime(snoozedUntil), id)
+	return updateCheck(err, res)
+}
+
+// SetReminderFlags 只更新启用和仅工作时间，不覆盖提醒时间和稍后提醒时间
+func (repo *SQLiteRepository) SetReminderFlags(id int64, enabled, workHoursOnly bool) error {
+	stmt := "update reminders set enabled = ?, work_hours_only = ? where id = ?"
+	res, err := repo.Conn.Exec(stmt, enabled, workHoursOnly, id)
+	return updateCheck(err, res)
+}
+
+func (repo *SQLiteRepository) DeleteReminder(id int64) error {
+	res, err := repo.Conn.Exec("delete from reminders where id = ?", id)
+	return deleteCheck(err, res)
+}
+
+// notification 相关方法实现
+func (repo *SQLiteRepository) InsertNotification(n Notification) (*Notification, error) {
+	stmt := "insert into notifications (kind, title, content, created_at, dismissed_at) values (?, ?, ?, ?, ?)"
+	res, err := repo.Conn.Exec(stmt, n.Kind, n.Title, n.Content, n.CreatedAt.Unix(), nullTime(n.DismissedAt))
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+
+	n.ID = id
+
+	return &n, nil
+}
+
+// RecentNotifications 最近的 limit 条通知，新的在前
+func (repo *SQLiteRepository) RecentNotifications(limit int) ([]Notification, error) {
+	query := "select id, kind, title, content, created_at, dismissed_at from notifications order by created_at desc, id desc limit ?"
+	rows, err := repo.Conn.Query(query, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var all []Notification
+	for rows.Next() {
+		var n Notification
+		var createdAt int64
+		var dismissedAt sql.NullInt64
+		if err := rows.Scan(&n.ID, &n.Kind, &n.Title, &n.Content, &createdAt, &dismissedAt); err != nil {
+			return nil, err
+		}
+		n.CreatedAt = time.Unix(createdAt, 0)
+		n.DismissedAt = timeFromNull(dismissedAt)
+		all = append(all, n)
+	}
+
+	return all, nil
+}
+
+// DismissNotification 标记通知已忽略
+func (repo *SQLiteRepository) DismissNotification(id int64, at time.Time) error {
+	res, err := repo.Conn.Exec("update notifications set dismissed_at = ? where id = ?", at.Unix(), id)
+	return updateCheck(err, res)
+}
+
+// focus session 相关方法实现
+func (repo *SQLiteRepository) InsertFocusSession(s FocusSession) (*FocusSession, error) {
+	stmt := `insert into focus_sessions (task_id, started_at, planned_minutes, ended_at, status, fish_count, penalty_points)
+		values (?, ?, ?, ?, ?, ?, ?)`
+	res, err := repo.Conn.Exec(stmt, nullID(s.TaskID), s.StartedAt.Unix(), s.PlannedMinutes, nullTime(s.EndedAt), s.Status, s.FishCount, s.PenaltyPoints)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+
+	s.ID = id
+
+	return &s, nil
+}
+
+func (repo *SQLiteRepository) UpdateFocusSession(id int64, updated FocusSession) error {
+	if id == 0 {
+		return errors.New("id cannot be 0")
+	}
+
+	stmt := `update focus_sessions set task_id = ?, started_at = ?, planned_minutes = ?, ended_at = ?, status = ?,
+		fish_count = ?, penalty_points = ? where id = ?`
+	res, err := repo.Conn.Exec(stmt, nullID(updated.TaskID), updated.StartedAt.Unix(), updated.PlannedMinutes, nullTime(updated.EndedAt),
+		updated.Status, updated.FishCount, updated.PenaltyPoints, id)
+	return updateCheck(err, res)
+}
+
+// FocusSessionsBetween 在 [from, to) 内开始的专注，按开始时间排序
+func (repo *SQLiteRepository) FocusSessionsBetween(from, to time.Time) ([]FocusSession, error) {
+	query := `select id, task_id, started_at, planned_minutes, ended_at, status, fish_count, penalty_points from focus_sessions
+		where started_at >= ? and started_at < ? order by started_at, id`
+	rows, err := repo.Conn.Query(query, from.Unix(), to.Unix())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var all []FocusSession
+	for rows.Next() {
+		s, err := scanFocusSession(rows)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, *s)
+	}
+
+	return all, nil
+}
+
+// ActiveFocusSession 最近一次还没结束的专注，没有时返回 sql.ErrNoRows
+func (repo *SQLiteRepository) ActiveFocusSession() (*FocusSession, error) {
+	query := `select id, task_id, started_at, planned_minutes, ended_at, status, fish_count, penalty_points from focus_sessions
+		where status = ? order by started_at desc, id desc limit 1`
+	return scanFocusSession(repo.Conn.QueryRow(query, FocusActive))
+}
+
+// backupTables 替换导入时清空的表：导入导出包含的表，以及引用任务、奖品的兑换记录、番茄钟和专注记录，
+// 否则这些旧记录会挂到导入后 id 相同的其他任务、奖品上
+var backupTables = []string{"redemptions", "pomodoros", "focus_sessions", "activity", "points_ledger", "summary", "rules", "prizes", "tasks"}
+
+// Import 导入备份，所有写入在同一个事务里完成，出错时不会留下一半的数据
+func (repo *SQLiteRepository) Import(b *Backup, mode string) error {
+	var local *Backup
+	if mode == ImportMerge {
+		var err error
+		if local, err = NewBackup(repo, time.Now()); err != nil {
+			return err
+		}
+	}
+	p, err := newImportPlan(b, mode, local)
+	if err != nil {
+		return err
+	}
+
+	tx, err := repo.Conn.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if mode == ImportReplace {
+		for _, table := range backupTables {
+			if _, err = tx.Exec("delete from " + table); err != nil {
+				return err
+			}
+		}
+		if _, err = tx.Exec("delete from settings where key = ?", SettingActiveTask); err != nil {
+			return err
+		}
+	}
+
+	insert := func(stmt string, args ...interface{}) (int64, error) {
+		res, err := tx.Exec(stmt, args...)
+		if err != nil {
+			return 0, err
+		}
+		return res.LastInsertId()
+	}
+
+	for _, t := range p.Tasks {
+		stmt := "insert into tasks (id, name, description, due_date, completed, completed_at, points, is_long_term, priority, estimate_minutes, spent_seconds) values (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)"
+		id, err := insert(stmt, nullID(p.id(t.ID)), t.Name, t.Description, t.DueDate.Unix(), t.Completed, nullTime(t.CompletedAt), t.Points, t.IsLongTerm, t.Priority, t.EstimateMinutes, t.SpentSeconds)
+		if err != nil {
+			return err
+		}
+		p.taskIDs[t.ID] = id
+	}
+	for _, prize := range p.Prizes {
+		stmt := "insert into prizes (id, description, points, is_repeat) values (?, ?, ?, ?)"
+		id, err := insert(stmt, nullID(p.id(prize.ID)), prize.Description, prize.Points, prize.IsRepeat)
+		if err != nil {
+			return err
+		}
+		p.prizeIDs[prize.ID] = id
+	}
+	for _, r := range p.Rules {
+		stmt := "insert into rules (id, kind, match_type, pattern, priority, enabled, focus_only) values (?, ?, ?, ?, ?, ?, ?)"
+		id, err := insert(stmt, nullID(p.id(r.ID)), r.Kind, r.MatchType, r.Pattern, r.Priority, r.Enabled, r.FocusOnly)
+		if err != nil {
+			return err
+		}
+		p.ruleIDs[r.ID] = id
+	}
+	for _, e := range p.Ledger {
+		stmt := "insert into points_ledger (id, kind, points, task_id, prize_id, created_at, note) values (?, ?, ?, ?, ?, ?, ?)"
+		_, err := insert(stmt, nullID(p.id(e.ID)), e.Kind, e.Points, nullID(p.taskIDs[e.TaskID]), nullID(p.prizeIDs[e.PrizeID]), e.CreatedAt.Unix(), e.Note)
+		if err != nil {
+			return err
+		}
+	}
+	for _, s := range p.Summaries {
+		stmt := "insert into summary (day, fish_count, finish_count, prize_count, productive_seconds, neutral_seconds, distracting_seconds) values (?, ?, ?, ?, ?, ?, ?)"
+		_, err := insert(stmt, s.Day, s.FishCount, s.FinishCount, s.PrizeCount, s.ProductiveSeconds, s.NeutralSeconds, s.DistractingSeconds)
+		if err != nil {
+			return err
+		}
+	}
+	for _, a := range p.Activity {
+		stmt := "insert into activity (id, started_at, ended_at, title, app, url, category, rule_id, samples) values (?, ?, ?, ?, ?, ?, ?, ?, ?)"
+		_, err := insert(stmt, nullID(p.id(a.ID)), a.StartedAt.Unix(), a.EndedAt.Unix(), a.Title, a.App, a.URL, a.Category, nullID(p.ruleIDs[a.RuleID]), a.Samples)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// rowScanner *sql.Row 和 *sql.Rows 共有的 Scan
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanFocusSession(row rowScanner) (*FocusSession, error) {
+	var s FocusSession
+	var taskID, endedAt sql.NullInt64
+	var startedAt int64
+	err := row.Scan(
+		&s.ID,
+		&taskID,
+		&startedAt,
+		&s.PlannedMinutes,
+		&endedAt,
+		&s.Status,
+		&s.FishCount,
+		&s.PenaltyPoints,
+	)
+	if err != nil {
+		return nil, err
+	}
+	s.TaskID = taskID.Int64
+	s.StartedAt = time.Unix(startedAt, 0)
+	s.EndedAt = timeFromNull(endedAt)
+
+	return &s, nil
+}
+
+// sameActivity b 能否合并到 a 后面：内容相同并且时间相连
+func sameActivity(a, b Activity) bool {
+	return a.Title == b.Title &&
+		a.App == b.App &&
+		a.URL == b.URL &&
+		a.Category == b.Category &&
+		a.RuleID == b.RuleID &&
+		b.StartedAt.Unix() <= a.EndedAt.Unix()
+}
+
+// nullTime 零值时间存为 null
+func nullTime(t time.Time) interface{} {
+	if t.IsZero() {
+		return nil
+	}
+	return t.Unix()
+}
+
+func timeFromNull(n sql.NullInt64) time.Time {
+	if !n.Valid {
+		return time.Time{}
+	}
+	return time.Unix(n.Int64, 0)
+}
+
+// nullID 0 表示没有关联，存为 null
+func nullID(id int64) interface{} {
+	if id == 0 {
+		return nil
+	}
+	return id
+}
+
 func deleteCheck(err error, res sql.Result) error {
 	if err != nil {
 		return err
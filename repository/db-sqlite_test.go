@@ -0,0 +1,67 @@
+package repository
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSQLiteRepository_Migrate(t *testing.T) {
+	err := testRepo.Migrate()
+	if err != nil {
+		t.Error("migrate failed:", err)
+	}
+}
+
+func TestSQLiteRepository_Contract(t *testing.T) {
+	testRepositoryContract(t, func(t *testing.T) Repository {
+		db, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "sql.db"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { db.Close() })
+
+		repo := NewSQLiteRepository(db)
+		if err = repo.Migrate(); err != nil {
+			t.Fatal("migrate failed:", err)
+		}
+		return repo
+	})
+}
+
+// 批量写入中途失败时，之前写入的行也要回滚
+func TestSQLiteRepository_InsertBatchRollback(t *testing.T) {
+	db, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "sql.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	repo := NewSQLiteRepository(db)
+	if err = repo.Migrate(); err != nil {
+		t.Fatal("migrate failed:", err)
+	}
+	for _, stmt := range []string{
+		"create trigger fail_task before insert on tasks when new.name = '坏' begin select raise(abort, 'bad task'); end",
+		"create trigger fail_prize before insert on prizes when new.description = '坏' begin select raise(abort, 'bad prize'); end",
+	} {
+		if _, err = db.Exec(stmt); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, err = repo.InsertTasks([]Task{{Name: "好", DueDate: time.Now()}, {Name: "坏", DueDate: time.Now()}}); err == nil {
+		t.Error("expected error for bad task")
+	}
+	if tasks, _ := repo.AllTasks(); len(tasks) != 0 {
+		t.Error("tasks not rolled back:", tasks)
+	}
+
+	if _, err = repo.InsertPrizes([]Prize{{Description: "好"}, {Description: "坏"}}); err == nil {
+		t.Error("expected error for bad prize")
+	}
+	if prizes, _ := repo.AllPrizes(); len(prizes) != 0 {
+		t.Error("prizes not rolled back:", prizes)
+	}
+}
@@ -1,66 +1,1084 @@
 package repository
 
 import (
+	"database/sql"
+	"errors"
+	"sort"
+	"sync"
 	"time"
 )
 
-type TestRepository struct{}
+// TestRepository 内存实现的 Repository，行为与 SQLiteRepository 保持一致，
+// 用于不需要数据库文件的测试
+type TestRepository struct {
+	mu            sync.Mutex
+	ids           map[string]int64
+	tasks         []Task
+	prizes        []Prize
+	redemptions   []Redemption
+	ledger        []LedgerEntry
+	summaries     map[string]Summary
+	rules         []Rule
+	settings      map[string]string
+	activity      []Activity
+	workRanges    []WorkRange
+	exceptions    []ScheduleException
+	pomodoros     []Pomodoro
+	reminders     []Reminder
+	notifications []Notification
+	focusSessions []FocusSession
+	migrated      bool
+}
 
 func NewTestRepository() *TestRepository {
-	return &TestRepository{}
+	return &TestRepository{
+		ids:       map[string]int64{},
+		summaries: map[string]Summary{},
+		settings:  map[string]string{},
+	}
 }
 
-// Migrate creates the table(s) we need
+// Migrate 和 sqlite 一样，第一次执行时写入默认规则、工作时间和提醒
 func (repo *TestRepository) Migrate() error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	if repo.migrated {
+		return nil
+	}
+	for _, r := range defaultRules {
+		r.ID = repo.nextID("rules")
+		repo.rules = append(repo.rules, r)
+	}
+	for _, r := range defaultWorkRanges(9, 18) {
+		r.ID = repo.nextID("work_ranges")
+		repo.workRanges = append(repo.workRanges, r)
+	}
+	for _, r := range defaultReminders {
+		r.ID = repo.nextID("reminders")
+		repo.reminders = append(repo.reminders, r)
+	}
+	repo.migrated = true
 
 	return nil
 }
 
-func (repo *TestRepository) InsertHolding(holdings Holdings) (*Holdings, error) {
+// nextID 模拟 autoincrement，删除后的 id 不会复用
+func (repo *TestRepository) nextID(table string) int64 {
+	repo.ids[table]++
+	return repo.ids[table]
+}
+
+// task 相关方法实现
+func (repo *TestRepository) InsertTask(t Task) (*Task, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	t.ID = repo.nextID("tasks")
+	t.DueDate = toSeconds(t.DueDate)
+	t.CompletedAt = toSeconds(t.CompletedAt)
+	repo.tasks = append(repo.tasks, t)
+
+	return &t, nil
+}
+
+func (repo *TestRepository) InsertTasks(tasks []Task) ([]Task, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	var inserted []Task
+	for _, t := range tasks {
+		t.ID = repo.nextID("tasks")
+		t.DueDate = toSeconds(t.DueDate)
+		t.CompletedAt = toSeconds(t.CompletedAt)
+		inserted = append(inserted, t)
+	}
+	repo.tasks = append(repo.tasks, inserted...)
+
+	return inserted, nil
+}
+
+func (repo *TestRepository) AllTasks() ([]Task, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	var all []Task
+	all = append(all, repo.tasks...)
+	sort.SliceStable(all, func(i, j int) bool {
+		return all[i].DueDate.Before(all[j].DueDate)
+	})
+
+	return all, nil
+}
 
-	return &holdings, nil
+func (repo *TestRepository) GetTaskByID(id int) (*Task, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	i := repo.taskIndex(int64(id))
+	if i < 0 {
+		return nil, sql.ErrNoRows
+	}
+	t := repo.tasks[i]
+
+	return &t, nil
+}
+
+func (repo *TestRepository) UpdateTask(id int64, updated Task) error {
+	if id == 0 {
+		return errors.New("id cannot be 0")
+	}
+
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	i := repo.taskIndex(id)
+	if i < 0 {
+		return errUpdateFailed
+	}
+	updated.ID = id
+	updated.DueDate = toSeconds(updated.DueDate)
+	updated.CompletedAt = toSeconds(updated.CompletedAt)
+	repo.tasks[i] = updated
+
+	return nil
+}
+
+func (repo *TestRepository) DeleteTask(id int64) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	i := repo.taskIndex(id)
+	if i < 0 {
+		return errUpdateFailed
+	}
+	repo.tasks = append(repo.tasks[:i], repo.tasks[i+1:]...)
+
+	return nil
+}
+
+func (repo *TestRepository) AddTaskTime(id int64, seconds int) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	i := repo.taskIndex(id)
+	if i < 0 {
+		return errUpdateFailed
+	}
+	repo.tasks[i].SpentSeconds += int64(seconds)
 
+	return nil
 }
 
-// AllHoldings returns all holdings, by purchase date
-func (repo *TestRepository) AllHoldings() ([]Holdings, error) {
+func (repo *TestRepository) CompleteTask(id int64) (*Task, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
 
-	var all []Holdings
-	h := Holdings{
-		Amount:        1,
-		PurchaseDate:  time.Now(),
-		PurchasePrice: 1000,
+	i := repo.taskIndex(id)
+	if i < 0 {
+		return nil, sql.ErrNoRows
+	}
+	t := repo.tasks[i]
+	if t.Completed {
+		return nil, ErrTaskCompleted
 	}
-	all = append(all, h)
 
-	h = Holdings{
-		Amount:        2,
-		PurchaseDate:  time.Now(),
-		PurchasePrice: 2000,
+	t.Completed = true
+	t.CompletedAt = toSeconds(time.Now())
+	repo.tasks[i] = t
+
+	repo.appendLedger(LedgerEntry{
+		Kind:      LedgerEarned,
+		Points:    t.Points,
+		TaskID:    t.ID,
+		CreatedAt: t.CompletedAt,
+		Note:      "完成任务：" + t.Name,
+	})
+	repo.incrementSummary(t.CompletedAt.Format("2006-01-02"), 0, 1, t.Points)
+
+	return &t, nil
+}
+
+func (repo *TestRepository) taskIndex(id int64) int {
+	for i, t := range repo.tasks {
+		if t.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// prize 相关方法实现
+func (repo *TestRepository) InsertPrize(p Prize) (*Prize, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	p.ID = repo.nextID("prizes")
+	repo.prizes = append(repo.prizes, p)
+
+	return &p, nil
+}
+
+func (repo *TestRepository) InsertPrizes(prizes []Prize) ([]Prize, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	var inserted []Prize
+	for _, p := range prizes {
+		p.ID = repo.nextID("prizes")
+		inserted = append(inserted, p)
 	}
-	all = append(all, h)
+	repo.prizes = append(repo.prizes, inserted...)
+
+	return inserted, nil
+}
+
+func (repo *TestRepository) AllPrizes() ([]Prize, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	var all []Prize
+	all = append(all, repo.prizes...)
 
 	return all, nil
 }
 
-func (repo *TestRepository) GetHoldingByID(id int) (*Holdings, error) {
-	h := Holdings{
-		Amount:        1,
-		PurchaseDate:  time.Now(),
-		PurchasePrice: 1000,
+func (repo *TestRepository) GetPrizeByID(id int) (*Prize, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	i := repo.prizeIndex(int64(id))
+	if i < 0 {
+		return nil, sql.ErrNoRows
 	}
+	p := repo.prizes[i]
 
-	return &h, nil
+	return &p, nil
+}
+
+func (repo *TestRepository) UpdatePrize(id int64, updated Prize) error {
+	if id == 0 {
+		return errors.New("id cannot be 0")
+	}
 
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	i := repo.prizeIndex(id)
+	if i < 0 {
+		return errUpdateFailed
+	}
+	updated.ID = id
+	repo.prizes[i] = updated
+
+	return nil
 }
-func (repo *TestRepository) UpdateHolding(id int64, update Holdings) error {
+
+func (repo *TestRepository) DeletePrize(id int64) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	i := repo.prizeIndex(id)
+	if i < 0 {
+		return errUpdateFailed
+	}
+	repo.prizes = append(repo.prizes[:i], repo.prizes[i+1:]...)
 
 	return nil
+}
+
+func (repo *TestRepository) RedeemPrize(id int64) (*Redemption, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	i := repo.prizeIndex(id)
+	if i < 0 {
+		return nil, sql.ErrNoRows
+	}
+	p := repo.prizes[i]
 
+	if repo.balance() < p.Points {
+		return nil, ErrInsufficientPoints
+	}
+
+	r := Redemption{
+		ID:          repo.nextID("redemptions"),
+		PrizeID:     p.ID,
+		Description: p.Description,
+		Points:      p.Points,
+		RedeemedAt:  toSeconds(time.Now()),
+	}
+	repo.appendLedger(LedgerEntry{
+		Kind:      LedgerSpent,
+		Points:    -p.Points,
+		PrizeID:   p.ID,
+		CreatedAt: r.RedeemedAt,
+		Note:      "兑换奖品：" + p.Description,
+	})
+	repo.redemptions = append(repo.redemptions, r)
+
+	if p.IsRepeat == 0 {
+		repo.prizes = append(repo.prizes[:i], repo.prizes[i+1:]...)
+	}
+
+	return &r, nil
 }
 
-func (repo *TestRepository) DeleteHolding(id int64) error {
+func (repo *TestRepository) AllRedemptions() ([]Redemption, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	var all []Redemption
+	all = append(all, repo.redemptions...)
+	sort.SliceStable(all, func(i, j int) bool {
+		if !all[i].RedeemedAt.Equal(all[j].RedeemedAt) {
+			return all[i].RedeemedAt.After(all[j].RedeemedAt)
+		}
+		return all[i].ID > all[j].ID
+	})
+
+	return all, nil
+}
+
+func (repo *TestRepository) prizeIndex(id int64) int {
+	for i, p := range repo.prizes {
+		if p.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// summary 相关方法实现
+func (repo *TestRepository) UpsertSummary(s Summary) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	if existing, ok := repo.summaries[s.Day]; ok {
+		s.ID = existing.ID
+	} else {
+		s.ID = repo.nextID("summary")
+	}
+	repo.summaries[s.Day] = s
+
+	return nil
+}
+
+func (repo *TestRepository) IncrementSummary(day string, fish, finish, points int) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	repo.incrementSummary(day, fish, finish, points)
 
 	return nil
+}
+
+func (repo *TestRepository) incrementSummary(day string, fish, finish, points int) {
+	s, ok := repo.summaries[day]
+	if !ok {
+		s = Summary{ID: repo.nextID("summary"), Day: day}
+	}
+	s.FishCount += int64(fish)
+	s.FinishCount += int64(finish)
+	s.PrizeCount += int64(points)
+	repo.summaries[day] = s
+}
+
+func (repo *TestRepository) IncrementSummaryTime(day, category string, seconds int) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	s, ok := repo.summaries[day]
+	if !ok {
+		s = Summary{ID: repo.nextID("summary"), Day: day}
+	}
+	switch category {
+	case CategoryProductive:
+		s.ProductiveSeconds += int64(seconds)
+	case CategoryNeutral:
+		s.NeutralSeconds += int64(seconds)
+	case CategoryDistracting:
+		s.DistractingSeconds += int64(seconds)
+	default:
+		return errUnknownCategory
+	}
+	repo.summaries[day] = s
+
+	return nil
+}
+
+func (repo *TestRepository) GetSummaryByDay(day string) (*Summary, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	s, ok := repo.summaries[day]
+	if !ok {
+		return &Summary{Day: day}, nil
+	}
+
+	return &s, nil
+}
+
+func (repo *TestRepository) SummariesBetween(from, to string) ([]Summary, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	var all []Summary
+	for day, s := range repo.summaries {
+		if day >= from && day <= to {
+			all = append(all, s)
+		}
+	}
+	sort.Slice(all, func(i, j int) bool {
+		return all[i].Day < all[j].Day
+	})
+
+	return all, nil
+}
+
+// points ledger 相关方法实现
+func (repo *TestRepository) InsertLedgerEntry(e LedgerEntry) (*LedgerEntry, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	if e.CreatedAt.IsZero() {
+		e.CreatedAt = time.Now()
+	}
+
+	return repo.appendLedger(e), nil
+}
+
+func (repo *TestRepository) appendLedger(e LedgerEntry) *LedgerEntry {
+	e.ID = repo.nextID("points_ledger")
+	e.CreatedAt = toSeconds(e.CreatedAt)
+	repo.ledger = append(repo.ledger, e)
+	return &e
+}
+
+func (repo *TestRepository) AllLedgerEntries() ([]LedgerEntry, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	var all []LedgerEntry
+	all = append(all, repo.ledger...)
+	sort.SliceStable(all, func(i, j int) bool {
+		if !all[i].CreatedAt.Equal(all[j].CreatedAt) {
+			return all[i].CreatedAt.Before(all[j].CreatedAt)
+		}
+		return all[i].ID < all[j].ID
+	})
+
+	return all, nil
+}
+
+func (repo *TestRepository) PointsBalance() (int, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	return repo.balance(), nil
+}
+
+func (repo *TestRepository) balance() int {
+	var balance int
+	for _, e := range repo.ledger {
+		balance += e.Points
+	}
+	return balance
+}
+
+// rule 相关方法实现
+func (repo *TestRepository) InsertRule(r Rule) (*Rule, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	if r.MatchType == "" {
+		r.MatchType = MatchTitle
+	}
+	r.ID = repo.nextID("rules")
+	repo.rules = append(repo.rules, r)
+
+	return &r, nil
+}
+
+func (repo *TestRepository) AllRules() ([]Rule, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	var all []Rule
+	all = append(all, repo.rules...)
+
+	return all, nil
+}
+
+func (repo *TestRepository) GetRuleByID(id int) (*Rule, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	i := repo.ruleIndex(int64(id))
+	if i < 0 {
+		return nil, sql.ErrNoRows
+	}
+	r := repo.rules[i]
+
+	return &r, nil
+}
+
+func (repo *TestRepository) UpdateRule(id int64, updated Rule) error {
+	if id == 0 {
+		return errors.New("id cannot be 0")
+	}
+
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	i := repo.ruleIndex(id)
+	if i < 0 {
+		return errUpdateFailed
+	}
+	if updated.MatchType == "" {
+		updated.MatchType = MatchTitle
+	}
+	updated.ID = id
+	repo.rules[i] = updated
+
+	return nil
+}
+
+func (repo *TestRepository) DeleteRule(id int64) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	i := repo.ruleIndex(id)
+	if i < 0 {
+		return errUpdateFailed
+	}
+	repo.rules = append(repo.rules[:i], repo.rules[i+1:]...)
+
+	return nil
+}
+
+func (repo *TestRepository) ruleIndex(id int64) int {
+	for i, r := range repo.rules {
+		if r.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// setting 相关方法实现
+func (repo *TestRepository) AllSettings() (map[string]string, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
 
+	all := map[string]string{}
+	for key, value := range repo.settings {
+		all[key] = value
+	}
+
+	return all, nil
+}
+
+func (repo *TestRepository) SaveSetting(key, value string) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	repo.settings[key] = value
+
+	return nil
+}
+
+// activity 相关方法实现
+func (repo *TestRepository) RecordActivity(a Activity) (*Activity, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	if a.Samples == 0 {
+		a.Samples = 1
+	}
+	a.StartedAt = toSeconds(a.StartedAt)
+	a.EndedAt = toSeconds(a.EndedAt)
+
+	if n := len(repo.activity); n > 0 && sameActivity(repo.activity[n-1], a) {
+		last := &repo.activity[n-1]
+		last.EndedAt = a.EndedAt
+		last.Samples += a.Samples
+		merged := *last
+		return &merged, nil
+	}
+
+	a.ID = repo.nextID("activity")
+	repo.activity = append(repo.activity, a)
+
+	return &a, nil
+}
+
+func (repo *TestRepository) ActivitiesBetween(from, to time.Time) ([]Activity, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	var all []Activity
+	for _, a := range repo.activity {
+		if a.EndedAt.Unix() > from.Unix() && a.StartedAt.Unix() < to.Unix() {
+			all = append(all, a)
+		}
+	}
+	sort.SliceStable(all, func(i, j int) bool {
+		return all[i].StartedAt.Before(all[j].StartedAt)
+	})
+
+	return all, nil
+}
+
+func (repo *TestRepository) TimeByApp(from, to time.Time) ([]ActivityTotal, error) {
+	return repo.activityTotals(func(a Activity) string { return a.App }, from, to)
+}
+
+func (repo *TestRepository) TimeByTitle(from, to time.Time) ([]ActivityTotal, error) {
+	return repo.activityTotals(func(a Activity) string { return a.Title }, from, to)
+}
+
+func (repo *TestRepository) activityTotals(key func(a Activity) string, from, to time.Time) ([]ActivityTotal, error) {
+	activities, _ := repo.ActivitiesBetween(from, to)
+
+	seconds := map[string]int64{}
+	for _, a := range activities {
+		start, end := a.StartedAt.Unix(), a.EndedAt.Unix()
+		if start < from.Unix() {
+			start = from.Unix()
+		}
+		if end > to.Unix() {
+			end = to.Unix()
+		}
+		seconds[key(a)] += end - start
+	}
+
+	var all []ActivityTotal
+	for k, v := range seconds {
+		all = append(all, ActivityTotal{Key: k, Seconds: v})
+	}
+	sort.Slice(all, func(i, j int) bool {
+		if all[i].Seconds != all[j].Seconds {
+			return all[i].Seconds > all[j].Seconds
+		}
+		return all[i].Key < all[j].Key
+	})
+
+	return all, nil
+}
+
+// schedule 相关方法实现
+func (repo *TestRepository) InsertWorkRange(r WorkRange) (*WorkRange, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	r.ID = repo.nextID("work_ranges")
+	repo.workRanges = append(repo.workRanges, r)
+
+	return &r, nil
+}
+
+func (repo *TestRepository) AllWorkRanges() ([]WorkRange, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	var all []WorkRange
+	all = append(all, repo.workRanges...)
+	sort.SliceStable(all, func(i, j int) bool {
+		if all[i].Weekday != all[j].Weekday {
+			return all[i].Weekday < all[j].Weekday
+		}
+		return all[i].Start < all[j].Start
+	})
+
+	return all, nil
+}
+
+func (repo *TestRepository) DeleteWorkRange(id int64) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	for i, r := range repo.workRanges {
+		if r.ID == id {
+			repo.workRanges = append(repo.workRanges[:i], repo.workRanges[i+1:]...)
+			return nil
+		}
+	}
+	return errUpdateFailed
+}
+
+func (repo *TestRepository) InsertScheduleException(e ScheduleException) (*ScheduleException, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	e.ID = repo.nextID("schedule_exceptions")
+	repo.exceptions = append(repo.exceptions, e)
+
+	return &e, nil
+}
+
+func (repo *TestRepository) AllScheduleExceptions() ([]ScheduleException, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	var all []ScheduleException
+	all = append(all, repo.exceptions...)
+	sort.SliceStable(all, func(i, j int) bool {
+		if all[i].Day != all[j].Day {
+			return all[i].Day < all[j].Day
+		}
+		return all[i].Start < all[j].Start
+	})
+
+	return all, nil
+}
+
+func (repo *TestRepository) DeleteScheduleException(id int64) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	for i, e := range repo.exceptions {
+		if e.ID == id {
+			repo.exceptions = append(repo.exceptions[:i], repo.exceptions[i+1:]...)
+			return nil
+		}
+	}
+	return errUpdateFailed
+}
+
+// pomodoro 相关方法实现
+func (repo *TestRepository) InsertPomodoro(p Pomodoro) (*Pomodoro, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	p.ID = repo.nextID("pomodoros")
+	p.StartedAt = toSeconds(p.StartedAt)
+	p.EndedAt = toSeconds(p.EndedAt)
+	repo.pomodoros = append(repo.pomodoros, p)
+
+	return &p, nil
+}
+
+func (repo *TestRepository) PomodorosBetween(from, to time.Time) ([]Pomodoro, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	from, to = toSeconds(from), toSeconds(to)
+	var all []Pomodoro
+	for _, p := range repo.pomodoros {
+		if !p.EndedAt.Before(from) && p.EndedAt.Before(to) {
+			all = append(all, p)
+		}
+	}
+	sort.SliceStable(all, func(i, j int) bool {
+		return all[i].EndedAt.Before(all[j].EndedAt)
+	})
+
+	return all, nil
+}
+
+func (repo *TestRepository) PomodoroCountByTask() (map[int64]int, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	counts := map[int64]int{}
+	for _, p := range repo.pomodoros {
+		if p.TaskID != 0 {
+			counts[p.TaskID]++
+		}
+	}
+
+	return counts, nil
+}
+
+// reminder 相关方法实现
+func (repo *TestRepository) InsertReminder(r Reminder) (*Reminder, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	r.ID = repo.nextID("reminders")
+	r.LastFiredAt = toSeconds(r.LastFiredAt)
+	r.SnoozedUntil = toSeconds(r.SnoozedUntil)
+	repo.reminders = append(repo.reminders, r)
+
+	return &r, nil
+}
+
+func (repo *TestRepository) AllReminders() ([]Reminder, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	var all []Reminder
+	all = append(all, repo.reminders...)
+
+	return all, nil
+}
+
+func (repo *TestRepository) UpdateReminder(id int64, updated Reminder) error {
+	if id == 0 {
+		return errors.New("id cannot be 0")
+	}
+
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	i := repo.reminderIndex(id)
+	if i < 0 {
+		return errUpdateFailed
+	}
+	updated.ID = id
+	updated.LastFiredAt = toSeconds(updated.LastFiredAt)
+	updated.SnoozedUntil = toSeconds(updated.SnoozedUntil)
+	repo.reminders[i] = updated
+
+	return nil
+}
+
+func (repo *TestRepository) MarkReminder(id int64, firedAt, snoozedUntil time.Time) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	i := repo.reminderIndex(id)
+	if i < 0 {
+		return errUpdateFailed
+	}
+	repo.reminders[i].LastFiredAt = toSeconds(firedAt)
+	repo.reminders[i].SnoozedUntil = toSeconds(snoozedUntil)
+
+	return nil
+}
+
+func (repo *TestRepository) SetReminderFlags(id int64, enabled, workHoursOnly bool) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	i := repo.reminderIndex(id)
+	if i < 0 {
+		return errUpdateFailed
+	}
+	repo.reminders[i].Enabled = enabled
+	repo.reminders[i].WorkHoursOnly = workHoursOnly
+
+	return nil
+}
+
+func (repo *TestRepository) DeleteReminder(id int64) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	i := repo.reminderIndex(id)
+	if i < 0 {
+		return errUpdateFailed
+	}
+	repo.reminders = append(repo.reminders[:i], repo.reminders[i+1:]...)
+
+	return nil
+}
+
+func (repo *TestRepository) reminderIndex(id int64) int {
+	for i, r := range repo.reminders {
+		if r.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// notification 相关方法实现
+func (repo *TestRepository) InsertNotification(n Notification) (*Notification, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	n.ID = repo.nextID("notifications")
+	n.CreatedAt = toSeconds(n.CreatedAt)
+	n.DismissedAt = toSeconds(n.DismissedAt)
+	repo.notifications = append(repo.notifications, n)
+
+	return &n, nil
+}
+
+func (repo *TestRepository) RecentNotifications(limit int) ([]Notification, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	var all []Notification
+	all = append(all, repo.notifications...)
+	sort.SliceStable(all, func(i, j int) bool {
+		if !all[i].CreatedAt.Equal(all[j].CreatedAt) {
+			return all[i].CreatedAt.After(all[j].CreatedAt)
+		}
+		return all[i].ID > all[j].ID
+	})
+	if len(all) > limit {
+		all = all[:limit]
+	}
+
+	return all, nil
+}
+
+func (repo *TestRepository) DismissNotification(id int64, at time.Time) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	for i, n := range repo.notifications {
+		if n.ID == id {
+			repo.notifications[i].DismissedAt = toSeconds(at)
+			return nil
+		}
+	}
+	return errUpdateFailed
+}
+
+// toSeconds 和 sqlite 一样只保留到秒
+func toSeconds(t time.Time) time.Time {
+	if t.IsZero() {
+		return t
+	}
+	return time.Unix(t.Unix(), 0)
+}
+
+// focus session 相关方法实现
+func (repo *TestRepository) InsertFocusSession(s FocusSession) (*FocusSession, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	s.ID = repo.nextID("focus_sessions")
+	s.StartedAt = toSeconds(s.StartedAt)
+	s.EndedAt = toSeconds(s.EndedAt)
+	repo.focusSessions = append(repo.focusSessions, s)
+
+	return &s, nil
+}
+
+func (repo *TestRepository) UpdateFocusSession(id int64, updated FocusSession) error {
+	if id == 0 {
+		return errors.New("id cannot be 0")
+	}
+
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	for i := range repo.focusSessions {
+		if repo.focusSessions[i].ID == id {
+			updated.ID = id
+			updated.StartedAt = toSeconds(updated.StartedAt)
+			updated.EndedAt = toSeconds(updated.EndedAt)
+			repo.focusSessions[i] = updated
+			return nil
+		}
+	}
+	return errUpdateFailed
+}
+
+func (repo *TestRepository) FocusSessionsBetween(from, to time.Time) ([]FocusSession, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	from, to = toSeconds(from), toSeconds(to)
+	var all []FocusSession
+	for _, s := range repo.focusSessions {
+		if !s.StartedAt.Before(from) && s.StartedAt.Before(to) {
+			all = append(all, s)
+		}
+	}
+	sort.SliceStable(all, func(i, j int) bool {
+		return all[i].StartedAt.Before(all[j].StartedAt)
+	})
+
+	return all, nil
+}
+
+func (repo *TestRepository) ActiveFocusSession() (*FocusSession, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	var active *FocusSession
+	for i := range repo.focusSessions {
+		s := repo.focusSessions[i]
+		if s.Status == FocusActive && (active == nil || !s.StartedAt.Before(active.StartedAt)) {
+			active = &s
+		}
+	}
+	if active == nil {
+		return nil, sql.ErrNoRows
+	}
+
+	return active, nil
+}
+
+// Import 导入备份，先检查完再写入，出错时不会留下一半的数据
+func (repo *TestRepository) Import(b *Backup, mode string) error {
+	var local *Backup
+	if mode == ImportMerge {
+		var err error
+		if local, err = NewBackup(repo, time.Now()); err != nil {
+			return err
+		}
+	}
+	p, err := newImportPlan(b, mode, local)
+	if err != nil {
+		return err
+	}
+
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	if mode == ImportReplace {
+		repo.tasks, repo.prizes, repo.ledger, repo.rules, repo.activity = nil, nil, nil, nil, nil
+		repo.redemptions, repo.pomodoros, repo.focusSessions = nil, nil, nil
+		repo.summaries = map[string]Summary{}
+		delete(repo.settings, SettingActiveTask)
+	}
+
+	// id 用导入文件里的或者自动分配，保证之后分配的 id 不会重复
+	assign := func(table string, id int64) int64 {
+		if id = p.id(id); id == 0 {
+			return repo.nextID(table)
+		}
+		if id > repo.ids[table] {
+			repo.ids[table] = id
+		}
+		return id
+	}
+
+	for _, t := range p.Tasks {
+		id := assign("tasks", t.ID)
+		p.taskIDs[t.ID] = id
+		t.ID = id
+		t.DueDate = toSeconds(t.DueDate)
+		t.CompletedAt = toSeconds(t.CompletedAt)
+		repo.tasks = append(repo.tasks, t)
+	}
+	for _, prize := range p.Prizes {
+		id := assign("prizes", prize.ID)
+		p.prizeIDs[prize.ID] = id
+		prize.ID = id
+		repo.prizes = append(repo.prizes, prize)
+	}
+	for _, r := range p.Rules {
+		id := assign("rules", r.ID)
+		p.ruleIDs[r.ID] = id
+		r.ID = id
+		repo.rules = append(repo.rules, r)
+	}
+	for _, e := range p.Ledger {
+		e.ID = assign("points_ledger", e.ID)
+		e.TaskID = p.taskIDs[e.TaskID]
+		e.PrizeID = p.prizeIDs[e.PrizeID]
+		e.CreatedAt = toSeconds(e.CreatedAt)
+		repo.ledger = append(repo.ledger, e)
+	}
+	for _, s := range p.Summaries {
+		s.ID = repo.nextID("summary")
+		repo.summaries[s.Day] = s
+	}
+	for _, a := range p.Activity {
+		a.ID = assign("activity", a.ID)
+		a.RuleID = p.ruleIDs[a.RuleID]
+		a.StartedAt = toSeconds(a.StartedAt)
+		a.EndedAt = toSeconds(a.EndedAt)
+		repo.activity = append(repo.activity, a)
+	}
+
+	return nil
 }
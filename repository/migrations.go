@@ -0,0 +1,429 @@
+package repository
+
+import (
+	"database/sql"
+	"strconv"
+	"time"
+)
+
+// migration 一次数据库结构变更，按 version 从小到大执行，
+// 执行过的版本记录在 schema_migrations 表里，不会重复执行
+type migration struct {
+	version int
+	name    string
+	up      func(tx *sql.Tx) error
+}
+
+// migrations 所有的数据库变更，只能在末尾追加，已发布的不要修改。
+// 最早的几个版本使用 if not exists，兼容引入版本管理之前创建的数据库
+var migrations = []migration{
+	{
+		version: 1,
+		name:    "create prizes, tasks and summary",
+		up: execSQL(`
+	create table if not exists prizes(
+		id integer primary key autoincrement,
+		description text not null,
+		points int not null,
+		is_repeat int not null
+		);
+	create table if not exists tasks(
+		id integer primary key autoincrement,
+		name varchar(20) not null,
+		description text not null,
+		due_date int not null,
+		completed int not null,
+		points int not null,
+		is_long_term int not null,
+		priority int not null
+		);
+	create table if not exists summary(
+		id integer primary key autoincrement,
+		fish_count integer not null,
+		finish_count integer not null,
+		prize_count integer not null,
+		day varchar(10) not null
+		);
+	`),
+	},
+	{
+		version: 2,
+		name:    "create points ledger",
+		up: execSQL(`
+	create table if not exists points_ledger(
+		id integer primary key autoincrement,
+		kind varchar(10) not null,
+		points int not null,
+		task_id integer,
+		prize_id integer,
+		created_at int not null,
+		note text not null
+		);
+	`),
+	},
+	{
+		version: 3,
+		name:    "create redemptions",
+		up: execSQL(`
+	create table if not exists redemptions(
+		id integer primary key autoincrement,
+		prize_id integer not null,
+		description text not null,
+		points int not null,
+		redeemed_at int not null
+		);
+	`),
+	},
+	{
+		version: 4,
+		name:    "add tasks.completed_at",
+		up:      addColumn("tasks", "completed_at", "int"),
+	},
+	{
+		version: 5,
+		name:    "unique summary day",
+		up:      execSQL(`create unique index if not exists summary_day on summary(day);`),
+	},
+	{
+		version: 6,
+		name:    "create rules and settings",
+		up: func(tx *sql.Tx) error {
+			query := `
+	create table if not exists rules(
+		id integer primary key autoincrement,
+		kind varchar(10) not null,
+		pattern text not null,
+		enabled int not null
+		);
+	create table if not exists settings(
+		key varchar(50) primary key,
+		value text not null
+		);
+	`
+			if _, err := tx.Exec(query); err != nil {
+				return err
+			}
+			for _, r := range defaultRules {
+				_, err := tx.Exec("insert into rules (kind, pattern, enabled) values (?, ?, ?)", r.Kind, r.Pattern, r.Enabled)
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	},
+	{
+		version: 7,
+		name:    "add rules.match_type and rules.priority",
+		up: func(tx *sql.Tx) error {
+			err := addColumn("rules", "match_type", "varchar(10) not null default 'title'")(tx)
+			if err != nil {
+				return err
+			}
+			return addColumn("rules", "priority", "int not null default 0")(tx)
+		},
+	},
+	{
+		version: 8,
+		name:    "add summary category seconds",
+		up: func(tx *sql.Tx) error {
+			for _, column := range []string{"productive_seconds", "neutral_seconds", "distracting_seconds"} {
+				if err := addColumn("summary", column, "integer not null default 0")(tx); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	},
+	{
+		version: 9,
+		name:    "create activity",
+		up: execSQL(`
+	create table if not exists activity(
+		id integer primary key autoincrement,
+		started_at int not null,
+		ended_at int not null,
+		title text not null,
+		app text not null,
+		url text not null,
+		category varchar(12) not null,
+		rule_id integer,
+		samples int not null
+		);
+	create index if not exists activity_started_at on activity(started_at);
+	`),
+	},
+	{
+		version: 10,
+		name:    "create work schedule",
+		up: func(tx *sql.Tx) error {
+			query := `
+	create table if not exists work_ranges(
+		id integer primary key autoincrement,
+		weekday int not null,
+		start_minute int not null,
+		end_minute int not null
+		);
+	create table if not exists schedule_exceptions(
+		id integer primary key autoincrement,
+		day varchar(10) not null,
+		kind varchar(10) not null,
+		start_minute int not null,
+		end_minute int not null,
+		note text not null
+		);
+	create index if not exists schedule_exceptions_day on schedule_exceptions(day);
+	`
+			if _, err := tx.Exec(query); err != nil {
+				return err
+			}
+
+			// 沿用设置里原来的上下班时间
+			begin, end := 9, 18
+			rows, err := tx.Query("select key, value from settings where key in ('begin_time', 'end_time')")
+			if err != nil {
+				return err
+			}
+			for rows.Next() {
+				var key, value string
+				if err := rows.Scan(&key, &value); err != nil {
+					rows.Close()
+					return err
+				}
+				hour, err := strconv.Atoi(value)
+				if err != nil {
+					continue
+				}
+				if key == "begin_time" {
+					begin = hour
+				} else {
+					end = hour
+				}
+			}
+			rows.Close()
+			if err := rows.Err(); err != nil {
+				return err
+			}
+
+			for _, r := range defaultWorkRanges(begin, end) {
+				stmt := "insert into work_ranges (weekday, start_minute, end_minute) values (?, ?, ?)"
+				if _, err := tx.Exec(stmt, r.Weekday, r.Start, r.End); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	},
+	{
+		version: 11,
+		name:    "create pomodoros",
+		up: execSQL(`
+	create table if not exists pomodoros(
+		id integer primary key autoincrement,
+		task_id integer,
+		started_at int not null,
+		ended_at int not null,
+		seconds int not null
+		);
+	create index if not exists pomodoros_started_at on pomodoros(started_at);
+	`),
+	},
+	{
+		version: 12,
+		name:    "add tasks estimate and spent time",
+		up: func(tx *sql.Tx) error {
+			err := addColumn("tasks", "estimate_minutes", "int not null default 0")(tx)
+			if err != nil {
+				return err
+			}
+			return addColumn("tasks", "spent_seconds", "integer not null default 0")(tx)
+		},
+	},
+	{
+		version: 13,
+		name:    "create reminders",
+		up: func(tx *sql.Tx) error {
+			query := `
+	create table if not exists reminders(
+		id integer primary key autoincrement,
+		title text not null,
+		message text not null,
+		kind varchar(10) not null,
+		spec text not null,
+		enabled int not null,
+		work_hours_only int not null,
+		last_fired_at int,
+		snoozed_until int
+		);
+	`
+			if _, err := tx.Exec(query); err != nil {
+				return err
+			}
+			for _, r := range defaultReminders {
+				stmt := "insert into reminders (title, message, kind, spec, enabled, work_hours_only) values (?, ?, ?, ?, ?, ?)"
+				_, err := tx.Exec(stmt, r.Title, r.Message, r.Kind, r.Spec, r.Enabled, r.WorkHoursOnly)
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	},
+	{
+		version: 14,
+		name:    "create notifications",
+		up: execSQL(`
+	create table if not exists notifications(
+		id integer primary key autoincrement,
+		kind varchar(10) not null,
+		title text not null,
+		content text not null,
+		created_at int not null,
+		dismissed_at int
+		);
+	create index if not exists notifications_created_at on notifications(created_at);
+	`),
+	},
+	{
+		version: 15,
+		name:    "add rules focus_only",
+		up:      addColumn("rules", "focus_only", "int not null default 0"),
+	},
+	{
+		version: 16,
+		name:    "create focus sessions",
+		up: execSQL(`
+	create table if not exists focus_sessions(
+		id integer primary key autoincrement,
+		task_id integer,
+		started_at int not null,
+		planned_minutes int not null,
+		ended_at int,
+		status varchar(10) not null,
+		fish_count int not null,
+		penalty_points int not null
+		);
+	create index if not exists focus_sessions_started_at on focus_sessions(started_at);
+	`),
+	},
+}
+
+// defaultRules 新建数据库时写入的规则，即原来写死在代码里的黑白名单
+var defaultRules = []Rule{
+	{Kind: RuleAllow, MatchType: MatchTitle, Pattern: "微信读书", Enabled: true},
+	{Kind: RuleAllow, MatchType: MatchTitle, Pattern: "马士兵", Enabled: true},
+	{Kind: RuleAllow, MatchType: MatchTitle, Pattern: "知识星球", Enabled: true},
+	{Kind: RuleAllow, MatchType: MatchTitle, Pattern: "小报童", Enabled: true},
+	{Kind: RuleAllow, MatchType: MatchTitle, Pattern: "xzgedu", Enabled: true},
+	{Kind: RuleBlock, MatchType: MatchTitle, Pattern: "google", Enabled: true},
+	{Kind: RuleBlock, MatchType: MatchTitle, Pattern: "知乎", Enabled: true},
+	{Kind: RuleBlock, MatchType: MatchTitle, Pattern: "即刻", Enabled: true},
+}
+
+// defaultReminders 新建数据库时写入的提醒。
+// readme 里的久坐提醒是休息提醒（RestReminder），它在离开电脑时暂停计时，
+// 这里的久坐提醒只按固定间隔提醒，默认关闭，避免和休息提醒重复
+var defaultReminders = []Reminder{
+	{Title: "久坐提醒", Message: "坐了很久了，起来站一站、活动一下", Kind: ReminderInterval, Spec: "45", Enabled: false, WorkHoursOnly: true},
+	{Title: "喝水提醒", Message: "该喝水了", Kind: ReminderInterval, Spec: "60", Enabled: true, WorkHoursOnly: true},
+	{Title: "护眼提醒", Message: "看看 6 米外的地方，休息眼睛 20 秒", Kind: ReminderInterval, Spec: "20", Enabled: false, WorkHoursOnly: true},
+}
+
+// defaultWorkRanges 新建日程时的默认工作时间：周一到周五 begin 点半到 end 点，
+// 与原来写死的工作时间一致，周末不再算工作时间
+func defaultWorkRanges(begin, end int) []WorkRange {
+	var ranges []WorkRange
+	if end*60 <= begin*60+30 {
+		begin, end = 9, 18
+	}
+	for weekday := time.Monday; weekday <= time.Friday; weekday++ {
+		ranges = append(ranges, WorkRange{Weekday: int(weekday), Start: begin*60 + 30, End: end * 60})
+	}
+	return ranges
+}
+
+// Migrate 把数据库升级到最新版本，所有待执行的变更在同一个事务里完成
+func (repo *SQLiteRepository) Migrate() error {
+	query := `
+	create table if not exists schema_migrations(
+		version integer primary key,
+		name text not null,
+		applied_at int not null
+		);
+	`
+	_, err := repo.Conn.Exec(query)
+	if err != nil {
+		return err
+	}
+
+	current, err := repo.SchemaVersion()
+	if err != nil {
+		return err
+	}
+
+	tx, err := repo.Conn.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, m := range migrations {
+		if m.version <= current {
+			continue
+		}
+		if err = m.up(tx); err != nil {
+			return err
+		}
+		stmt := "insert into schema_migrations (version, name, applied_at) values (?, ?, ?)"
+		_, err = tx.Exec(stmt, m.version, m.name, time.Now().Unix())
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// SchemaVersion 当前数据库已执行到的版本，没有执行过任何变更为 0
+func (repo *SQLiteRepository) SchemaVersion() (int, error) {
+	var version int
+	err := repo.Conn.QueryRow("select coalesce(max(version), 0) from schema_migrations").Scan(&version)
+	if err != nil {
+		return 0, err
+	}
+	return version, nil
+}
+
+func execSQL(query string) func(tx *sql.Tx) error {
+	return func(tx *sql.Tx) error {
+		_, err := tx.Exec(query)
+		return err
+	}
+}
+
+// addColumn 给表增加一列，列已存在时跳过
+func addColumn(table, column, definition string) func(tx *sql.Tx) error {
+	return func(tx *sql.Tx) error {
+		rows, err := tx.Query("select name from pragma_table_info(?)", table)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var name string
+			if err := rows.Scan(&name); err != nil {
+				return err
+			}
+			if name == column {
+				return nil
+			}
+		}
+		if err := rows.Err(); err != nil {
+			return err
+		}
+
+		_, err = tx.Exec("alter table " + table + " add column " + column + " " + definition)
+		return err
+	}
+}
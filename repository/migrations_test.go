@@ -0,0 +1,117 @@
+package repository
+
+import (
+	"database/sql"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestSQLiteRepository_MigrateOldSchema 引入版本管理之前创建的数据库要能直接升级
+func TestSQLiteRepository_MigrateOldSchema(t *testing.T) {
+	data, err := os.ReadFile("./testdata/old-schema.db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "sql.db")
+	if err = os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	repo := NewSQLiteRepository(db)
+
+	if err = repo.Migrate(); err != nil {
+		t.Fatal("migrate old schema failed:", err)
+	}
+
+	version, err := repo.SchemaVersion()
+	if err != nil {
+		t.Fatal(err)
+	}
+	latest := migrations[len(migrations)-1].version
+	if version != latest {
+		t.Errorf("wrong schema version: expected %d, got %d", latest, version)
+	}
+
+	// 旧数据保留，新加的列可用
+	tasks, err := repo.AllTasks()
+	if err != nil {
+		t.Fatal("get tasks after migrate failed:", err)
+	}
+	if len(tasks) != 1 || tasks[0].Name != "读书" || !tasks[0].CompletedAt.IsZero() {
+		t.Error("old tasks not preserved:", tasks)
+	}
+	if _, err = repo.CompleteTask(tasks[0].ID); err != nil {
+		t.Error("complete migrated task failed:", err)
+	}
+
+	prizes, err := repo.AllPrizes()
+	if err != nil || len(prizes) != 1 {
+		t.Error("old prizes not preserved:", prizes, err)
+	}
+
+	// 再次执行不会重复变更
+	if err = repo.Migrate(); err != nil {
+		t.Error("second migrate failed:", err)
+	}
+	var applied int
+	_ = db.QueryRow("select count(*) from schema_migrations").Scan(&applied)
+	if applied != len(migrations) {
+		t.Errorf("migrations applied more than once: %d rows for %d migrations", applied, len(migrations))
+	}
+}
+
+func TestMigrations_Ordered(t *testing.T) {
+	for i, m := range migrations {
+		if m.version != i+1 {
+			t.Errorf("migration %q has version %d, expected %d", m.name, m.version, i+1)
+		}
+	}
+}
+
+// TestSQLiteRepository_MigrateWorkTime 已保存的上下班时间迁移为每周工作时间
+func TestSQLiteRepository_MigrateWorkTime(t *testing.T) {
+	db, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "sql.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	repo := NewSQLiteRepository(db)
+
+	// 先升级到引入日程之前的版本
+	_, err = db.Exec("create table schema_migrations(version integer primary key, name text not null, applied_at int not null)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx, _ := db.Begin()
+	for _, m := range migrations {
+		if m.version >= 10 {
+			break
+		}
+		if err = m.up(tx); err != nil {
+			t.Fatal(err)
+		}
+		_, _ = tx.Exec("insert into schema_migrations (version, name, applied_at) values (?, ?, 0)", m.version, m.name)
+	}
+	if err = tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	_ = repo.SaveSetting("begin_time", "8")
+	_ = repo.SaveSetting("end_time", "17")
+
+	if err = repo.Migrate(); err != nil {
+		t.Fatal("migrate failed:", err)
+	}
+	ranges, err := repo.AllWorkRanges()
+	if err != nil || len(ranges) != 5 {
+		t.Fatal("expected monday to friday ranges:", ranges, err)
+	}
+	if ranges[0].Start != 8*60+30 || ranges[0].End != 17*60 {
+		t.Error("saved work time not migrated:", ranges[0])
+	}
+}
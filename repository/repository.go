@@ -6,8 +6,13 @@ import (
 )
 
 var (
-	errUpdateFailed = errors.New("update failed")
-	errDeleteFailed = errors.New("delete failed")
+	errUpdateFailed    = errors.New("update failed")
+	errDeleteFailed    = errors.New("delete failed")
+	errUnknownCategory = errors.New("unknown category")
+	// ErrInsufficientPoints 兑换时积分不足
+	ErrInsufficientPoints = errors.New("insufficient points")
+	// ErrTaskCompleted 任务已经完成过
+	ErrTaskCompleted = errors.New("task already completed")
 )
 
 // Repository is the interface which must be satisfied in order to
@@ -16,24 +21,75 @@ type Repository interface {
 	Migrate() error
 	// tasks
 	InsertTask(t Task) (*Task, error)
+	InsertTasks(tasks []Task) ([]Task, error)
 	AllTasks() ([]Task, error)
 	GetTaskByID(id int) (*Task, error)
 	UpdateTask(id int64, updated Task) error
 	DeleteTask(id int64) error
+	CompleteTask(id int64) (*Task, error)
+	AddTaskTime(id int64, seconds int) error
 	//// prizes
 	InsertPrize(p Prize) (*Prize, error)
+	InsertPrizes(prizes []Prize) ([]Prize, error)
 	AllPrizes() ([]Prize, error)
 	GetPrizeByID(id int) (*Prize, error)
 	UpdatePrize(id int64, updated Prize) error
 	DeletePrize(id int64) error
-}
-
-// Holdings is the type for the user's gold holdings
-type Holdings struct {
-	ID            int64     `json:"id"`
-	Amount        int       `json:"amount"`
-	PurchaseDate  time.Time `json:"purchase_date"`
-	PurchasePrice int       `json:"purchase_price"`
+	RedeemPrize(id int64) (*Redemption, error)
+	AllRedemptions() ([]Redemption, error)
+	// summary
+	UpsertSummary(s Summary) error
+	IncrementSummary(day string, fish, finish, points int) error
+	IncrementSummaryTime(day, category string, seconds int) error
+	GetSummaryByDay(day string) (*Summary, error)
+	SummariesBetween(from, to string) ([]Summary, error)
+	// points ledger
+	InsertLedgerEntry(e LedgerEntry) (*LedgerEntry, error)
+	AllLedgerEntries() ([]LedgerEntry, error)
+	PointsBalance() (int, error)
+	// rules
+	InsertRule(r Rule) (*Rule, error)
+	AllRules() ([]Rule, error)
+	GetRuleByID(id int) (*Rule, error)
+	UpdateRule(id int64, updated Rule) error
+	DeleteRule(id int64) error
+	// settings
+	AllSettings() (map[string]string, error)
+	SaveSetting(key, value string) error
+	// activity
+	RecordActivity(a Activity) (*Activity, error)
+	ActivitiesBetween(from, to time.Time) ([]Activity, error)
+	TimeByApp(from, to time.Time) ([]ActivityTotal, error)
+	TimeByTitle(from, to time.Time) ([]ActivityTotal, error)
+	// schedule
+	InsertWorkRange(r WorkRange) (*WorkRange, error)
+	AllWorkRanges() ([]WorkRange, error)
+	DeleteWorkRange(id int64) error
+	InsertScheduleException(e ScheduleException) (*ScheduleException, error)
+	AllScheduleExceptions() ([]ScheduleException, error)
+	DeleteScheduleException(id int64) error
+	// pomodoros
+	InsertPomodoro(p Pomodoro) (*Pomodoro, error)
+	PomodorosBetween(from, to time.Time) ([]Pomodoro, error)
+	PomodoroCountByTask() (map[int64]int, error)
+	// reminders
+	InsertReminder(r Reminder) (*Reminder, error)
+	AllReminders() ([]Reminder, error)
+	UpdateReminder(id int64, updated Reminder) error
+	MarkReminder(id int64, firedAt, snoozedUntil time.Time) error
+	SetReminderFlags(id int64, enabled, workHoursOnly bool) error
+	DeleteReminder(id int64) error
+	// notifications
+	InsertNotification(n Notification) (*Notification, error)
+	RecentNotifications(limit int) ([]Notification, error)
+	DismissNotification(id int64, at time.Time) error
+	// focus sessions
+	InsertFocusSession(s FocusSession) (*FocusSession, error)
+	UpdateFocusSession(id int64, updated FocusSession) error
+	FocusSessionsBetween(from, to time.Time) ([]FocusSession, error)
+	ActiveFocusSession() (*FocusSession, error)
+	// backup
+	Import(b *Backup, mode string) error
 }
 
 type Task struct {
@@ -42,12 +98,18 @@ type Task struct {
 	Description string    `json:"description"`
 	DueDate     time.Time `json:"due_date"`
 	Completed   bool      `json:"completed"`
+	// 完成时间，未完成为零值
+	CompletedAt time.Time `json:"completed_at"`
 	// 对应积分
 	Points int `json:"points"`
 	// 短期 or 长期
 	IsLongTerm int `json:"is_long_term"`
 	// 优先级
 	Priority int `json:"priority"`
+	// 预估用时，单位分钟，0 表示没有预估
+	EstimateMinutes int `json:"estimate_minutes"`
+	// 实际用时，作为当前任务时的工作窗口时长，单位秒
+	SpentSeconds int64 `json:"spent_seconds"`
 }
 
 type Prize struct {
@@ -59,10 +121,227 @@ type Prize struct {
 	IsRepeat int `json:"is_repeat"`
 }
 
-type summary struct {
-	ID          int64  `json:"id"`
-	FishCount   int64  `json:"fish_count"`
-	FinishCount int64  `json:"finish_count"`
-	PrizeCount  int64  `json:"prize_count"`
-	day         string `json:"day"`
+// Redemption 奖品兑换记录，保存兑换时的奖品描述和积分
+type Redemption struct {
+	ID          int64     `json:"id"`
+	PrizeID     int64     `json:"prize_id"`
+	Description string    `json:"description"`
+	Points      int       `json:"points"`
+	RedeemedAt  time.Time `json:"redeemed_at"`
+}
+
+// 积分流水类型
+const (
+	LedgerEarned   = "earned"
+	LedgerSpent    = "spent"
+	LedgerAdjusted = "adjusted"
+	// LedgerPenalty 摸鱼次数过多被扣分
+	LedgerPenalty = "penalty"
+)
+
+// LedgerEntry 积分流水，每一次积分变动都对应一条记录
+type LedgerEntry struct {
+	ID   int64  `json:"id"`
+	Kind string `json:"kind"`
+	// 变动积分，获得为正，消耗为负
+	Points int `json:"points"`
+	// 来源任务或奖品，没有则为0
+	TaskID    int64     `json:"task_id"`
+	PrizeID   int64     `json:"prize_id"`
+	CreatedAt time.Time `json:"created_at"`
+	Note      string    `json:"note"`
+}
+
+// 规则类型
+const (
+	// RuleAllow 白名单，命中的窗口算作工作
+	RuleAllow = "allow"
+	// RuleBlock 黑名单，命中的窗口算作摸鱼
+	RuleBlock = "block"
+)
+
+// 规则匹配方式
+const (
+	// MatchTitle 窗口标题包含 Pattern，不区分大小写
+	MatchTitle = "title"
+	// MatchRegex 窗口标题匹配正则 Pattern
+	MatchRegex = "regex"
+	// MatchApp 应用/进程名等于 Pattern
+	MatchApp = "app"
+	// MatchURL 浏览器当前网址的域名是 Pattern 或其子域名
+	MatchURL = "url"
+)
+
+// Rule 摸鱼检测规则，多条规则同时命中时 Priority 大的生效，
+// 优先级相同时白名单优先
+type Rule struct {
+	ID        int64  `json:"id"`
+	Kind      string `json:"kind"`
+	MatchType string `json:"match_type"`
+	Pattern   string `json:"pattern"`
+	Priority  int    `json:"priority"`
+	Enabled   bool   `json:"enabled"`
+	// 只在专注时生效，用来在专注时额外屏蔽一些窗口
+	FocusOnly bool `json:"focus_only"`
+}
+
+// 窗口分类
+const (
+	// CategoryProductive 命中白名单，在工作/学习
+	CategoryProductive = "productive"
+	// CategoryNeutral 没有命中任何规则
+	CategoryNeutral = "neutral"
+	// CategoryDistracting 命中黑名单，在摸鱼
+	CategoryDistracting = "distracting"
+	// CategoryIdle 离开电脑，不算工作也不算摸鱼
+	CategoryIdle = "idle"
+)
+
+// Activity 一段连续相同的窗口采样，相邻的相同采样会合并成一条
+type Activity struct {
+	ID        int64     `json:"id"`
+	StartedAt time.Time `json:"started_at"`
+	EndedAt   time.Time `json:"ended_at"`
+	Title     string    `json:"title"`
+	App       string    `json:"app"`
+	URL       string    `json:"url"`
+	Category  string    `json:"category"`
+	// 命中的规则，没有命中为0
+	RuleID int64 `json:"rule_id"`
+	// 合并的采样次数
+	Samples int `json:"samples"`
+}
+
+// ActivityTotal 按应用或标题汇总的使用时长
+type ActivityTotal struct {
+	Key     string `json:"key"`
+	Seconds int64  `json:"seconds"`
+}
+
+// WorkRange 每周固定的一段工作时间，同一天可以有多段（比如中间留出午休），
+// Start、End 是从零点开始的分钟数，不包含 End
+type WorkRange struct {
+	ID int64 `json:"id"`
+	// 0 为周日，与 time.Weekday 一致
+	Weekday int `json:"weekday"`
+	Start   int `json:"start"`
+	End     int `json:"end"`
+}
+
+// 日程例外类型
+const (
+	// ExceptionOff 休假/节假日，这段时间不算工作时间
+	ExceptionOff = "off"
+	// ExceptionWork 临时加班/调休上班，这段时间额外算工作时间
+	ExceptionWork = "work"
+)
+
+// ScheduleException 某一天对每周工作时间的调整，Day 格式为 2006-01-02，
+// Start、End 同 WorkRange，整天为 0 到 1440
+type ScheduleException struct {
+	ID    int64  `json:"id"`
+	Day   string `json:"day"`
+	Kind  string `json:"kind"`
+	Start int    `json:"start"`
+	End   int    `json:"end"`
+	Note  string `json:"note"`
+}
+
+// Pomodoro 一个完成的番茄钟专注
+type Pomodoro struct {
+	ID int64 `json:"id"`
+	// 专注的任务，没有关联任务为0
+	TaskID    int64     `json:"task_id"`
+	StartedAt time.Time `json:"started_at"`
+	EndedAt   time.Time `json:"ended_at"`
+	// 实际专注时长，不含暂停，单位秒
+	Seconds int `json:"seconds"`
+}
+
+// 提醒方式
+const (
+	// ReminderInterval 每隔 Spec 分钟提醒一次
+	ReminderInterval = "interval"
+	// ReminderFixed 每天在 Spec 列出的时间提醒，如 10:00,15:30
+	ReminderFixed = "fixed"
+	// ReminderCron Spec 为 5 段的 cron 表达式：分 时 日 月 星期
+	ReminderCron = "cron"
+)
+
+// Reminder 用户自定义的提醒，比如喝水、久坐、护眼
+type Reminder struct {
+	ID      int64  `json:"id"`
+	Title   string `json:"title"`
+	Message string `json:"message"`
+	Kind    string `json:"kind"`
+	Spec    string `json:"spec"`
+	Enabled bool   `json:"enabled"`
+	// 只在工作时间内提醒
+	WorkHoursOnly bool `json:"work_hours_only"`
+	// 上一次提醒的时间，没有提醒过为零值
+	LastFiredAt time.Time `json:"last_fired_at"`
+	// 稍后提醒的时间，没有推迟为零值
+	SnoozedUntil time.Time `json:"snoozed_until"`
+}
+
+// 通知类型
+const (
+	NotificationFish     = "fish"
+	NotificationRest     = "rest"
+	NotificationReminder = "reminder"
+	NotificationPomodoro = "pomodoro"
+	NotificationFocus    = "focus"
+	NotificationReport   = "report"
+)
+
+// Notification 发出过的通知
+type Notification struct {
+	ID        int64     `json:"id"`
+	Kind      string    `json:"kind"`
+	Title     string    `json:"title"`
+	Content   string    `json:"content"`
+	CreatedAt time.Time `json:"created_at"`
+	// 忽略的时间，没有忽略为零值
+	DismissedAt time.Time `json:"dismissed_at"`
+}
+
+// 专注状态
+const (
+	// FocusActive 正在专注
+	FocusActive = "active"
+	// FocusCompleted 专注满了计划时长
+	FocusCompleted = "completed"
+	// FocusAbandoned 提前结束
+	FocusAbandoned = "abandoned"
+)
+
+// FocusSession 一次专注：计划时长内使用更严格的规则，提前结束要扣积分
+type FocusSession struct {
+	ID int64 `json:"id"`
+	// 专注的任务，没有关联任务为0
+	TaskID    int64     `json:"task_id"`
+	StartedAt time.Time `json:"started_at"`
+	// 计划专注时长，单位分钟
+	PlannedMinutes int `json:"planned_minutes"`
+	// 结束时间，专注中为零值
+	EndedAt time.Time `json:"ended_at"`
+	Status  string    `json:"status"`
+	// 专注期间的摸鱼次数
+	FishCount int `json:"fish_count"`
+	// 提前结束扣除的积分
+	PenaltyPoints int `json:"penalty_points"`
+}
+
+// Summary 每日概况，Day 格式为 2006-01-02
+type Summary struct {
+	ID          int64 `json:"id"`
+	FishCount   int64 `json:"fish_count"`
+	FinishCount int64 `json:"finish_count"`
+	// 当日获得的积分
+	PrizeCount int64 `json:"prize_count"`
+	// 各类窗口的使用时长，单位秒
+	ProductiveSeconds  int64  `json:"productive_seconds"`
+	NeutralSeconds     int64  `json:"neutral_seconds"`
+	DistractingSeconds int64  `json:"distracting_seconds"`
+	Day                string `json:"day"`
 }
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"NoFish/repository"
+	"fmt"
+	"time"
+)
+
+// RestReminder 连续看电脑一段时间后提醒休息，离开电脑时暂停计时，
+// 离开足够久算作休息过，重新开始计时
+type RestReminder struct {
+	app  *Config
+	idle IdleProbe
+	// 时钟，测试时可以替换
+	now func() time.Time
+
+	// 本轮连续看电脑的时长
+	screenTime time.Duration
+	lastCheck  time.Time
+}
+
+// NewRestReminder 使用 idle 判断是否离开的休息提醒
+func NewRestReminder(app *Config, idle IdleProbe) *RestReminder {
+	return &RestReminder{
+		app:  app,
+		idle: idle,
+		now:  time.Now,
+	}
+}
+
+// Run 提醒休息一下，只在工作时间内计时
+func (r *RestReminder) Run() {
+	for {
+		time.Sleep(currentSampleInterval())
+		if isInWorkTime(r.now()) {
+			r.Check()
+		}
+	}
+}
+
+// Check 更新连续看电脑的时长，需要休息时发出提醒并返回 true
+func (r *RestReminder) Check() bool {
+	now := r.now()
+	elapsed := elapsedSince(r.lastCheck, now)
+	r.lastCheck = now
+
+	rulesLock.RLock()
+	interval := time.Duration(restInterval) * time.Minute
+	breakTime := time.Duration(restBreak) * time.Minute
+	notify := notifyRest
+	rulesLock.RUnlock()
+
+	idle := r.idle.IdleTime()
+	if idle >= breakTime {
+		// 已经离开足够久，算休息过了
+		r.screenTime = 0
+		return false
+	}
+	if isIdle(r.idle) {
+		return false
+	}
+
+	r.screenTime += elapsed
+	if r.screenTime < interval {
+		return false
+	}
+
+	r.screenTime = 0
+	if notify {
+		r.app.notify(repository.NotificationRest, "休息一下", fmt.Sprintf("看电脑%d分钟了，休息一下比较好", int(interval.Minutes())))
+	}
+	return true
+}
+
+// ScreenTime 本轮连续看电脑的时长
+func (r *RestReminder) ScreenTime() time.Duration {
+	return r.screenTime
+}
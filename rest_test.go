@@ -0,0 +1,47 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRestReminder_Check(t *testing.T) {
+	testApp.loadFishRules()
+
+	clock := time.Date(2023, 2, 6, 10, 0, 0, 0, time.Local)
+	idle := time.Duration(0)
+	r := NewRestReminder(&testApp, IdleFunc(func() time.Duration { return idle }))
+	r.now = func() time.Time { return clock }
+	step := func(n int) (reminded int) {
+		for i := 0; i < n; i++ {
+			if r.Check() {
+				reminded++
+			}
+			clock = clock.Add(sampleInterval)
+		}
+		return reminded
+	}
+
+	// 20 分钟 = 60 次采样
+	if n := step(59); n != 0 {
+		t.Error("reminded too early")
+	}
+	if n := step(1); n != 1 {
+		t.Error("expected a reminder after 20 minutes")
+	}
+
+	// 短暂离开只暂停计时
+	step(30)
+	idle = 4 * time.Minute
+	step(10)
+	if r.ScreenTime() != 10*time.Minute {
+		t.Errorf("short idle should pause the timer, got %v", r.ScreenTime())
+	}
+
+	// 离开超过 5 分钟算休息过，重新计时
+	idle = 6 * time.Minute
+	step(1)
+	if r.ScreenTime() != 0 {
+		t.Errorf("real break should restart the timer, got %v", r.ScreenTime())
+	}
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"NoFish/repository"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// Sample 一次活动窗口采样
+type Sample struct {
+	Title string
+	// 应用/进程名
+	App string
+	// 浏览器当前网址，非浏览器为空
+	URL string
+}
+
+// RuleMatch 一次规则匹配的结果
+type RuleMatch struct {
+	// 生效的规则，没有命中为 nil
+	Rule *repository.Rule
+	// 同时命中但被覆盖的规则
+	Overridden []repository.Rule
+}
+
+// Explain 说明命中了哪条规则，用于日志
+func (m RuleMatch) Explain() string {
+	if m.Rule == nil {
+		return "未命中任何规则"
+	}
+	explain := "命中规则 " + describeRule(*m.Rule)
+	for _, r := range m.Overridden {
+		explain += "，覆盖 " + describeRule(r)
+	}
+	return explain
+}
+
+func describeRule(r repository.Rule) string {
+	return fmt.Sprintf("#%d[%s/%s 优先级%d]%q", r.ID, r.Kind, r.MatchType, r.Priority, r.Pattern)
+}
+
+type compiledRule struct {
+	rule repository.Rule
+	re   *regexp.Regexp
+}
+
+// RuleEngine 按规则判断一次采样是工作还是摸鱼
+type RuleEngine struct {
+	rules []compiledRule
+}
+
+// NewRuleEngine 编译启用的规则，正则写错的规则会被跳过并返回错误
+func NewRuleEngine(rules []repository.Rule) (*RuleEngine, []error) {
+	var errs []error
+	engine := &RuleEngine{}
+
+	for _, r := range rules {
+		if !r.Enabled {
+			continue
+		}
+		c := compiledRule{rule: r}
+		switch r.MatchType {
+		case repository.MatchRegex:
+			re, err := regexp.Compile("(?i)" + r.Pattern)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("规则 #%d 正则错误: %w", r.ID, err))
+				continue
+			}
+			c.re = re
+		case repository.MatchTitle, repository.MatchApp, repository.MatchURL, "":
+			c.rule.Pattern = strings.ToLower(r.Pattern)
+		default:
+			errs = append(errs, fmt.Errorf("规则 #%d 匹配方式未知: %s", r.ID, r.MatchType))
+			continue
+		}
+		engine.rules = append(engine.rules, c)
+	}
+
+	return engine, errs
+}
+
+// Match 找出对采样生效的规则：优先级大的优先，优先级相同时白名单优先
+func (e *RuleEngine) Match(s Sample) RuleMatch {
+	var result RuleMatch
+	if e == nil {
+		return result
+	}
+
+	title := strings.ToLower(s.Title)
+	app := strings.ToLower(s.App)
+	host := urlHost(s.URL)
+
+	for i := range e.rules {
+		c := e.rules[i]
+		if !c.matches(title, app, host) {
+			continue
+		}
+		r := c.rule
+		if result.Rule == nil {
+			result.Rule = &r
+			continue
+		}
+		if outranks(r, *result.Rule) {
+			result.Overridden = append(result.Overridden, *result.Rule)
+			result.Rule = &r
+		} else {
+			result.Overridden = append(result.Overridden, r)
+		}
+	}
+
+	return result
+}
+
+func (c compiledRule) matches(title, app, host string) bool {
+	switch c.rule.MatchType {
+	case repository.MatchRegex:
+		return c.re.MatchString(title)
+	case repository.MatchApp:
+		return app != "" && app == c.rule.Pattern
+	case repository.MatchURL:
+		return host != "" && (host == c.rule.Pattern || strings.HasSuffix(host, "."+c.rule.Pattern))
+	default:
+		return c.rule.Pattern != "" && strings.Contains(title, c.rule.Pattern)
+	}
+}
+
+// outranks a 是否比 b 优先
+func outranks(a, b repository.Rule) bool {
+	if a.Priority != b.Priority {
+		return a.Priority > b.Priority
+	}
+	return a.Kind == repository.RuleAllow && b.Kind != repository.RuleAllow
+}
+
+// urlHost 取出网址的域名，没有协议的网址也可以
+func urlHost(raw string) string {
+	if raw == "" {
+		return ""
+	}
+	if !strings.Contains(raw, "://") {
+		raw = "https://" + raw
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return ""
+	}
+	return strings.ToLower(u.Hostname())
+}
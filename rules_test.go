@@ -0,0 +1,51 @@
+package main
+
+import (
+	"NoFish/repository"
+	"testing"
+)
+
+func TestRuleEngine_Match(t *testing.T) {
+	rules := []repository.Rule{
+		{ID: 1, Kind: repository.RuleBlock, MatchType: repository.MatchTitle, Pattern: "google", Enabled: true},
+		{ID: 2, Kind: repository.RuleAllow, MatchType: repository.MatchRegex, Pattern: `google (docs|文档)`, Enabled: true},
+		{ID: 3, Kind: repository.RuleBlock, MatchType: repository.MatchApp, Pattern: "WeChat", Enabled: true},
+		{ID: 4, Kind: repository.RuleBlock, MatchType: repository.MatchURL, Pattern: "bilibili.com", Enabled: true},
+		{ID: 5, Kind: repository.RuleAllow, MatchType: repository.MatchURL, Pattern: "bilibili.com", Priority: -1, Enabled: true},
+		{ID: 6, Kind: repository.RuleBlock, MatchType: repository.MatchTitle, Pattern: "知乎", Enabled: false},
+		{ID: 7, Kind: repository.RuleBlock, MatchType: repository.MatchRegex, Pattern: `(`, Enabled: true},
+	}
+	engine, errs := NewRuleEngine(rules)
+	if len(errs) != 1 {
+		t.Errorf("expected 1 error for the bad regex, got %v", errs)
+	}
+
+	tests := []struct {
+		name   string
+		sample Sample
+		ruleID int64
+	}{
+		{"title contains", Sample{Title: "Google 搜索"}, 1},
+		{"allow overrides block at same priority", Sample{Title: "周报 - Google Docs"}, 2},
+		{"app name exact", Sample{Title: "聊天", App: "wechat"}, 3},
+		{"app name not partial", Sample{Title: "聊天", App: "wechat helper"}, 0},
+		{"url subdomain", Sample{Title: "视频", URL: "https://www.bilibili.com/video/1"}, 4},
+		{"disabled rule ignored", Sample{Title: "知乎 - 首页"}, 0},
+		{"no match", Sample{Title: "main.go - NoFish"}, 0},
+	}
+	for _, tt := range tests {
+		match := engine.Match(tt.sample)
+		var got int64
+		if match.Rule != nil {
+			got = match.Rule.ID
+		}
+		if got != tt.ruleID {
+			t.Errorf("%s: expected rule %d, got %d (%s)", tt.name, tt.ruleID, got, match.Explain())
+		}
+	}
+
+	match := engine.Match(Sample{Title: "周报 - Google Docs"})
+	if len(match.Overridden) != 1 || match.Overridden[0].ID != 1 {
+		t.Error("overridden rule not reported:", match.Explain())
+	}
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"NoFish/repository"
+	"fmt"
+	"time"
+)
+
+// Schedule 工作时间表：每周固定的工作时间段，加上某些日期的休假和加班
+type Schedule struct {
+	ranges     map[time.Weekday][]repository.WorkRange
+	exceptions map[string][]repository.ScheduleException
+}
+
+// NewSchedule 由每周工作时间和日程例外组成的工作时间表
+func NewSchedule(ranges []repository.WorkRange, exceptions []repository.ScheduleException) *Schedule {
+	s := &Schedule{
+		ranges:     map[time.Weekday][]repository.WorkRange{},
+		exceptions: map[string][]repository.ScheduleException{},
+	}
+	for _, r := range ranges {
+		weekday := time.Weekday(r.Weekday)
+		s.ranges[weekday] = append(s.ranges[weekday], r)
+	}
+	for _, e := range exceptions {
+		s.exceptions[e.Day] = append(s.exceptions[e.Day], e)
+	}
+	return s
+}
+
+// Active t 是否在工作时间内：落在当天的工作时间段或加班时间段里，
+// 并且不在休假时间段里，休假优先
+func (s *Schedule) Active(t time.Time) bool {
+	if s == nil {
+		return false
+	}
+
+	minute := t.Hour()*60 + t.Minute()
+	active := false
+	for _, r := range s.ranges[t.Weekday()] {
+		if minute >= r.Start && minute < r.End {
+			active = true
+		}
+	}
+	for _, e := range s.exceptions[t.Format("2006-01-02")] {
+		if minute < e.Start || minute >= e.End {
+			continue
+		}
+		if e.Kind == repository.ExceptionOff {
+			return false
+		}
+		active = true
+	}
+	return active
+}
+
+// WorkEnd day 这一天最后一段工作时间的结束时间，这一天不用工作返回零值
+func (s *Schedule) WorkEnd(day time.Time) time.Time {
+	start := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, day.Location())
+	for minute := 24*60 - 1; minute >= 0; minute-- {
+		if s.Active(start.Add(time.Duration(minute) * time.Minute)) {
+			return start.Add(time.Duration(minute+1) * time.Minute)
+		}
+	}
+	return time.Time{}
+}
+
+// formatMinute 从零点开始的分钟数显示为 15:04
+func formatMinute(minute int) string {
+	return fmt.Sprintf("%02d:%02d", minute/60, minute%60)
+}
+
+// parseMinute 解析 15:04 格式的时间为从零点开始的分钟数，允许 24:00 表示一天结束
+func parseMinute(text string) (int, error) {
+	var hour, minute int
+	if _, err := fmt.Sscanf(text, "%d:%d", &hour, &minute); err != nil {
+		return 0, fmt.Errorf("时间格式应为 时:分，比如 09:30")
+	}
+	if hour < 0 || minute < 0 || minute > 59 || hour*60+minute > 24*60 {
+		return 0, fmt.Errorf("时间超出范围: %s", text)
+	}
+	return hour*60 + minute, nil
+}
+
+// minuteValidator 时:分 格式的时间
+func minuteValidator(text string) error {
+	_, err := parseMinute(text)
+	return err
+}
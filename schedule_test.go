@@ -0,0 +1,106 @@
+package main
+
+import (
+	"NoFish/repository"
+	"testing"
+	"time"
+)
+
+func TestSchedule_Active(t *testing.T) {
+	monday := int(time.Monday)
+	s := NewSchedule(
+		[]repository.WorkRange{
+			{Weekday: monday, Start: 9*60 + 30, End: 12 * 60},
+			{Weekday: monday, Start: 13 * 60, End: 18 * 60},
+			{Weekday: int(time.Tuesday), Start: 9*60 + 30, End: 18 * 60},
+		},
+		[]repository.ScheduleException{
+			// 2023-01-03 周二休半天，2023-01-07 周六加班，2023-01-09 周一晚上加班
+			{Day: "2023-01-03", Kind: repository.ExceptionOff, Start: 13 * 60, End: 24 * 60},
+			{Day: "2023-01-07", Kind: repository.ExceptionWork, Start: 10 * 60, End: 12 * 60},
+			{Day: "2023-01-09", Kind: repository.ExceptionWork, Start: 18 * 60, End: 21 * 60},
+		},
+	)
+	at := func(day string, hour, minute int) time.Time {
+		d, _ := time.ParseInLocation("2006-01-02", day, time.Local)
+		return d.Add(time.Duration(hour)*time.Hour + time.Duration(minute)*time.Minute)
+	}
+
+	cases := []struct {
+		name   string
+		t      time.Time
+		active bool
+	}{
+		{"before work", at("2023-01-02", 9, 29), false},
+		{"morning", at("2023-01-02", 9, 30), true},
+		{"lunch break", at("2023-01-02", 12, 30), false},
+		{"afternoon", at("2023-01-02", 17, 59), true},
+		{"after work", at("2023-01-02", 18, 0), false},
+		{"half day off morning", at("2023-01-03", 10, 0), true},
+		{"half day off afternoon", at("2023-01-03", 14, 0), false},
+		{"sunday", at("2023-01-08", 10, 0), false},
+		{"saturday overtime", at("2023-01-07", 11, 0), true},
+		{"saturday after overtime", at("2023-01-07", 12, 0), false},
+		{"working late", at("2023-01-09", 20, 0), true},
+		{"after working late", at("2023-01-09", 21, 0), false},
+	}
+	for _, c := range cases {
+		if s.Active(c.t) != c.active {
+			t.Errorf("%s: expected active=%v at %s", c.name, c.active, c.t)
+		}
+	}
+
+	var empty *Schedule
+	if empty.Active(at("2023-01-02", 10, 0)) {
+		t.Error("nil schedule should never be active")
+	}
+
+	// 下班时间：休半天提前，加班推后，不用上班为零值
+	ends := []struct {
+		day string
+		end time.Time
+	}{
+		{"2023-01-02", at("2023-01-02", 18, 0)},
+		{"2023-01-03", at("2023-01-03", 13, 0)},
+		{"2023-01-07", at("2023-01-07", 12, 0)},
+		{"2023-01-08", time.Time{}},
+		{"2023-01-09", at("2023-01-09", 21, 0)},
+	}
+	for _, e := range ends {
+		if end := s.WorkEnd(at(e.day, 8, 0)); !end.Equal(e.end) {
+			t.Errorf("%s: expected work to end at %s, got %s", e.day, e.end, end)
+		}
+	}
+}
+
+func TestParseMinute(t *testing.T) {
+	for text, expected := range map[string]int{"09:30": 570, "0:00": 0, "24:00": 1440} {
+		if m, err := parseMinute(text); err != nil || m != expected {
+			t.Errorf("%q: expected %d, got %d %v", text, expected, m, err)
+		}
+	}
+	for _, text := range []string{"", "9", "12:60", "24:01", "-1:00"} {
+		if _, err := parseMinute(text); err == nil {
+			t.Errorf("%q should be invalid", text)
+		}
+	}
+	if formatMinute(570) != "09:30" {
+		t.Error("wrong format:", formatMinute(570))
+	}
+}
+
+func TestApp_loadFishRules_Schedule(t *testing.T) {
+	e, _ := testApp.DB.InsertScheduleException(repository.ScheduleException{Day: "2023-01-02", Kind: repository.ExceptionOff, Start: 0, End: 24 * 60})
+	testApp.loadFishRules()
+
+	monday := time.Date(2023, 1, 2, 10, 0, 0, 0, time.Local)
+	if isInWorkTime(monday) {
+		t.Error("holiday should not be work time")
+	}
+	if !isInWorkTime(monday.AddDate(0, 0, 7)) {
+		t.Error("default monday schedule not loaded")
+	}
+
+	_ = testApp.DB.DeleteScheduleException(e.ID)
+	testApp.loadFishRules()
+}
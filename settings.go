@@ -0,0 +1,727 @@
+package main
+
+import (
+	"NoFish/repository"
+	"errors"
+	"fmt"
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"strconv"
+	"time"
+)
+
+// 配置项的 key
+const (
+	settingWaitTime       = "wait_time"
+	settingIdleThreshold  = "idle_threshold"
+	settingRestInterval   = "rest_interval"
+	settingRestBreak      = "rest_break"
+	settingSampleInterval = "sample_interval"
+	settingNotifyFish     = "notify_fish"
+	settingNotifyRest     = "notify_rest"
+	// 番茄钟
+	settingPomodoroWork       = "pomodoro_work"
+	settingPomodoroShortBreak = "pomodoro_short_break"
+	settingPomodoroLongBreak  = "pomodoro_long_break"
+	settingPomodoroLongEvery  = "pomodoro_long_every"
+	settingNotifyPomodoro     = "notify_pomodoro"
+	// 当前任务
+	settingActiveTask = repository.SettingActiveTask
+	// 摸鱼升级处理
+	settingEscalateModalAt      = "escalate_modal_at"
+	settingEscalateDeductAt     = "escalate_deduct_at"
+	settingEscalateDeductPoints = "escalate_deduct_points"
+	settingEscalateFullScreenAt = "escalate_full_screen_at"
+
+	settingFocusMinutes     = "focus_minutes"
+	settingFocusWaitSeconds = "focus_wait_seconds"
+	settingFocusExitPenalty = "focus_exit_penalty"
+
+	// 上一次自动生成日报的日期和周报的周一
+	settingLastDailyReport  = "last_daily_report"
+	settingLastWeeklyReport = "last_weekly_report"
+)
+
+// 规则类型和匹配方式的中文名
+var ruleKindText = map[string]string{
+	repository.RuleAllow: "白名单",
+	repository.RuleBlock: "黑名单",
+}
+
+var matchTypeText = map[string]string{
+	repository.MatchTitle: "标题包含",
+	repository.MatchRegex: "标题正则",
+	repository.MatchApp:   "应用名",
+	repository.MatchURL:   "网址域名",
+}
+
+// intSetting 读取整数配置，不存在或格式不对时返回默认值
+func intSetting(settings map[string]string, key string, def int) int {
+	value, ok := settings[key]
+	if !ok {
+		return def
+	}
+	i, err := strconv.Atoi(value)
+	if err != nil {
+		return def
+	}
+	return i
+}
+
+// boolSetting 读取开关配置，"1" 为开，"0" 为关
+func boolSetting(settings map[string]string, key string, def bool) bool {
+	switch settings[key] {
+	case "1":
+		return true
+	case "0":
+		return false
+	}
+	return def
+}
+
+func boolText(b bool) string {
+	if b {
+		return "1"
+	}
+	return "0"
+}
+
+// setupDialog 设置，保存后立即对正在运行的检查生效
+func (app *Config) setupDialog() dialog.Dialog {
+	rulesLock.RLock()
+	waitEntry := intEntry(waitTime, intRangeValidator(1, 120))
+	sampleEntry := intEntry(int(sampleInterval.Seconds()), intRangeValidator(5, 300))
+	restEntry := intEntry(restInterval, intRangeValidator(1, 240))
+	restBreakEntry := intEntry(restBreak, intRangeValidator(1, 60))
+	idleEntry := intEntry(idleThreshold, intRangeValidator(1, 60))
+	// 检测不到离开时提示这一项不会生效
+	idleHint := ""
+	if err := idleSupported(); err != nil {
+		idleHint = "本机无法检测离开电脑，暂不生效"
+	}
+	notifyFishCheck := widget.NewCheck("摸鱼警告", nil)
+	notifyFishCheck.SetChecked(notifyFish)
+	notifyRestCheck := widget.NewCheck("休息提醒", nil)
+	notifyRestCheck.SetChecked(notifyRest)
+	notifyPomodoroCheck := widget.NewCheck("番茄钟", nil)
+	notifyPomodoroCheck.SetChecked(notifyPomodoro)
+	pomodoroWorkEntry := intEntry(pomodoroWork, intRangeValidator(1, 120))
+	pomodoroShortEntry := intEntry(pomodoroShortBreak, intRangeValidator(1, 60))
+	pomodoroLongEntry := intEntry(pomodoroLongBreak, intRangeValidator(1, 120))
+	pomodoroEveryEntry := intEntry(pomodoroLongEvery, intRangeValidator(1, 12))
+	modalAtEntry := intEntry(escalateModalAt, intRangeValidator(0, 100))
+	deductAtEntry := intEntry(escalateDeductAt, intRangeValidator(0, 100))
+	deductPointsEntry := intEntry(escalateDeductPoints, intRangeValidator(0, 100))
+	fullScreenAtEntry := intEntry(escalateFullScreenAt, intRangeValidator(0, 100))
+	focusMinutesEntry := intEntry(focusMinutes, intRangeValidator(1, 600))
+	focusWaitEntry := intEntry(focusWaitSeconds, intRangeValidator(5, 3600))
+	focusPenaltyEntry := intEntry(focusExitPenalty, intRangeValidator(0, 100))
+	rulesLock.RUnlock()
+
+	rulesButton := widget.NewButtonWithIcon("编辑规则", theme.ListIcon(), func() {
+		app.rulesDialog()
+	})
+	scheduleButton := widget.NewButtonWithIcon("编辑工作时间", theme.HistoryIcon(), func() {
+		app.scheduleDialog()
+	})
+	remindersButton := widget.NewButtonWithIcon("编辑提醒", theme.InfoIcon(), func() {
+		app.remindersDialog()
+	})
+
+	setupForm := dialog.NewForm(
+		"设置",
+		"保存",
+		"取消",
+		[]*widget.FormItem{
+			{Text: "工作时间", Widget: scheduleButton},
+			{Text: "摸鱼等待(分钟)", Widget: waitEntry},
+			{Text: "采样间隔(秒)", Widget: sampleEntry},
+			{Text: "休息提醒(分钟)", Widget: restEntry},
+			{Text: "休息时长(分钟)", Widget: restBreakEntry},
+			{Text: "离开判定(分钟)", Widget: idleEntry, HintText: idleHint},
+			{Text: "番茄专注(分钟)", Widget: pomodoroWorkEntry},
+			{Text: "短休息(分钟)", Widget: pomodoroShortEntry},
+			{Text: "长休息(分钟)", Widget: pomodoroLongEntry},
+			{Text: "几个番茄后长休息", Widget: pomodoroEveryEntry},
+			{Text: "通知", Widget: container.NewHBox(notifyFishCheck, notifyRestCheck, notifyPomodoroCheck)},
+			{Text: "第几次摸鱼弹窗", Widget: modalAtEntry},
+			{Text: "第几次摸鱼扣分", Widget: deductAtEntry},
+			{Text: "每次扣分", Widget: deductPointsEntry},
+			{Text: "第几次摸鱼全屏", Widget: fullScreenAtEntry},
+			{Text: "专注时长(分钟)", Widget: focusMinutesEntry},
+			{Text: "专注摸鱼等待(秒)", Widget: focusWaitEntry},
+			{Text: "提前结束专注扣分", Widget: focusPenaltyEntry},
+			{Text: "黑白名单", Widget: rulesButton},
+			{Text: "提醒", Widget: remindersButton},
+		},
+		func(valid bool) {
+			if !valid {
+				return
+			}
+			settings := map[string]string{
+				settingWaitTime:             waitEntry.Text,
+				settingSampleInterval:       sampleEntry.Text,
+				settingRestInterval:         restEntry.Text,
+				settingRestBreak:            restBreakEntry.Text,
+				settingIdleThreshold:        idleEntry.Text,
+				settingNotifyFish:           boolText(notifyFishCheck.Checked),
+				settingNotifyRest:           boolText(notifyRestCheck.Checked),
+				settingPomodoroWork:         pomodoroWorkEntry.Text,
+				settingPomodoroShortBreak:   pomodoroShortEntry.Text,
+				settingPomodoroLongBreak:    pomodoroLongEntry.Text,
+				settingPomodoroLongEvery:    pomodoroEveryEntry.Text,
+				settingNotifyPomodoro:       boolText(notifyPomodoroCheck.Checked),
+				settingEscalateModalAt:      modalAtEntry.Text,
+				settingEscalateDeductAt:     deductAtEntry.Text,
+				settingEscalateDeductPoints: deductPointsEntry.Text,
+				settingEscalateFullScreenAt: fullScreenAtEntry.Text,
+				settingFocusMinutes:         focusMinutesEntry.Text,
+				settingFocusWaitSeconds:     focusWaitEntry.Text,
+				settingFocusExitPenalty:     focusPenaltyEntry.Text,
+			}
+			for key, value := range settings {
+				if err := app.DB.SaveSetting(key, value); err != nil {
+					dialog.ShowError(err, app.MainWindow)
+					app.ErrorLog.Println(err)
+					return
+				}
+			}
+
+			// 立即生效
+			app.loadFishRules()
+		},
+		app.MainWindow)
+
+	setupForm.Resize(fyne.Size{Width: 420, Height: 660})
+	setupForm.Show()
+
+	return setupForm
+}
+
+// rulesDialog 编辑黑白名单规则，每次修改立即生效
+func (app *Config) rulesDialog() dialog.Dialog {
+	var rules []repository.Rule
+	var table *widget.Table
+
+	refresh := func() {
+		all, err := app.DB.AllRules()
+		if err != nil {
+			app.ErrorLog.Println(err)
+		}
+		rules = all
+		app.loadFishRules()
+		if table != nil {
+			table.Refresh()
+		}
+	}
+	refresh()
+
+	header := []string{"启用", "类型", "匹配方式", "内容", "优先级", "仅专注", "删除"}
+	table = widget.NewTable(
+		func() (int, int) {
+			return len(rules) + 1, len(header)
+		},
+		func() fyne.CanvasObject {
+			return container.NewVBox(widget.NewLabel(""))
+		},
+		func(i widget.TableCellID, o fyne.CanvasObject) {
+			if i.Row == 0 {
+				o.(*fyne.Container).Objects = []fyne.CanvasObject{widget.NewLabel(header[i.Col])}
+				return
+			}
+			r := rules[i.Row-1]
+			var w fyne.CanvasObject
+			switch i.Col {
+			case 0:
+				check := widget.NewCheck("", nil)
+				check.SetChecked(r.Enabled)
+				check.OnChanged = func(enabled bool) {
+					r.Enabled = enabled
+					if err := app.DB.UpdateRule(r.ID, r); err != nil {
+						app.ErrorLog.Println(err)
+					}
+					refresh()
+				}
+				w = check
+			case 1:
+				w = widget.NewLabel(ruleKindText[r.Kind])
+			case 2:
+				w = widget.NewLabel(matchTypeText[r.MatchType])
+			case 3:
+				w = widget.NewLabel(r.Pattern)
+			case 4:
+				w = widget.NewLabel(strconv.Itoa(r.Priority))
+			case 5:
+				check := widget.NewCheck("", nil)
+				check.SetChecked(r.FocusOnly)
+				check.OnChanged = func(focusOnly bool) {
+					r.FocusOnly = focusOnly
+					if err := app.DB.UpdateRule(r.ID, r); err != nil {
+						app.ErrorLog.Println(err)
+					}
+					refresh()
+				}
+				w = check
+			case 6:
+				button := widget.NewButtonWithIcon("", theme.DeleteIcon(), func() {
+					if err := app.DB.DeleteRule(r.ID); err != nil {
+						app.ErrorLog.Println(err)
+					}
+					refresh()
+				})
+				button.Importance = widget.HighImportance
+				w = button
+			}
+			o.(*fyne.Container).Objects = []fyne.CanvasObject{w}
+		})
+
+	colWidths := []float32{50, 70, 90, 250, 60, 60, 60}
+	for i := 0; i < len(colWidths); i++ {
+		table.SetColumnWidth(i, colWidths[i])
+	}
+
+	// 新增规则
+	kindSelect := widget.NewSelect([]string{"白名单", "黑名单"}, nil)
+	kindSelect.SetSelected("黑名单")
+	matchSelect := widget.NewSelect([]string{"标题包含", "标题正则", "应用名", "网址域名"}, nil)
+	matchSelect.SetSelected("标题包含")
+	patternEntry := widget.NewEntry()
+	patternEntry.PlaceHolder = "内容"
+	priorityEntry := widget.NewEntry()
+	priorityEntry.SetText("0")
+	priorityEntry.Validator = isIntValidator
+	focusOnlyCheck := widget.NewCheck("仅专注", nil)
+
+	addButton := widget.NewButtonWithIcon("添加", theme.ContentAddIcon(), func() {
+		priority, err := strconv.Atoi(priorityEntry.Text)
+		if err != nil || patternEntry.Text == "" {
+			dialog.ShowError(errors.New("请填写内容和整数优先级"), app.MainWindow)
+			return
+		}
+		r := repository.Rule{
+			Kind:      textKey(ruleKindText, kindSelect.Selected),
+			MatchType: textKey(matchTypeText, matchSelect.Selected),
+			Pattern:   patternEntry.Text,
+			Priority:  priority,
+			Enabled:   true,
+			FocusOnly: focusOnlyCheck.Checked,
+		}
+		if _, errs := NewRuleEngine([]repository.Rule{r}); len(errs) > 0 {
+			dialog.ShowError(errs[0], app.MainWindow)
+			return
+		}
+		if _, err = app.DB.InsertRule(r); err != nil {
+			dialog.ShowError(err, app.MainWindow)
+			app.ErrorLog.Println(err)
+			return
+		}
+		patternEntry.SetText("")
+		refresh()
+	})
+
+	addBar := container.NewBorder(nil, nil,
+		container.NewHBox(kindSelect, matchSelect),
+		container.NewHBox(priorityEntry, focusOnlyCheck, addButton),
+		patternEntry)
+	content := container.NewBorder(nil, addBar, nil, nil, table)
+
+	rulesDialog := dialog.NewCustom("黑白名单", "关闭", content, app.MainWindow)
+	rulesDialog.Resize(fyne.Size{Width: 760, Height: 450})
+	rulesDialog.Show()
+
+	return rulesDialog
+}
+
+// 星期的中文名，下标与 time.Weekday 一致
+var weekdayText = []string{"周日", "周一", "周二", "周三", "周四", "周五", "周六"}
+
+var exceptionKindText = map[string]string{
+	repository.ExceptionOff:  "休假",
+	repository.ExceptionWork: "加班",
+}
+
+// scheduleDialog 编辑每周工作时间和休假、加班，每次修改立即生效
+func (app *Config) scheduleDialog() dialog.Dialog {
+	var ranges []repository.WorkRange
+	var exceptions []repository.ScheduleException
+	var rangesTable, exceptionsTable *widget.Table
+
+	refresh := func() {
+		var err error
+		if ranges, err = app.DB.AllWorkRanges(); err != nil {
+			app.ErrorLog.Println(err)
+		}
+		if exceptions, err = app.DB.AllScheduleExceptions(); err != nil {
+			app.ErrorLog.Println(err)
+		}
+		app.loadFishRules()
+		if rangesTable != nil {
+			rangesTable.Refresh()
+			exceptionsTable.Refresh()
+		}
+	}
+	refresh()
+
+	// 每周工作时间
+	rangesHeader := []string{"星期", "开始", "结束", "删除"}
+	rangesTable = widget.NewTable(
+		func() (int, int) {
+			return len(ranges) + 1, len(rangesHeader)
+		},
+		func() fyne.CanvasObject {
+			return container.NewVBox(widget.NewLabel(""))
+		},
+		func(i widget.TableCellID, o fyne.CanvasObject) {
+			if i.Row == 0 {
+				o.(*fyne.Container).Objects = []fyne.CanvasObject{widget.NewLabel(rangesHeader[i.Col])}
+				return
+			}
+			r := ranges[i.Row-1]
+			var w fyne.CanvasObject
+			switch i.Col {
+			case 0:
+				w = widget.NewLabel(weekdayText[r.Weekday])
+			case 1:
+				w = widget.NewLabel(formatMinute(r.Start))
+			case 2:
+				w = widget.NewLabel(formatMinute(r.End))
+			case 3:
+				button := widget.NewButtonWithIcon("", theme.DeleteIcon(), func() {
+					if err := app.DB.DeleteWorkRange(r.ID); err != nil {
+						app.ErrorLog.Println(err)
+					}
+					refresh()
+				})
+				button.Importance = widget.HighImportance
+				w = button
+			}
+			o.(*fyne.Container).Objects = []fyne.CanvasObject{w}
+		})
+	for i, width := range []float32{80, 80, 80, 60} {
+		rangesTable.SetColumnWidth(i, width)
+	}
+
+	weekdaySelect := widget.NewSelect(weekdayText, nil)
+	weekdaySelect.SetSelected(weekdayText[time.Monday])
+	rangeStart := timeEntry(9*60 + 30)
+	rangeEnd := timeEntry(12 * 60)
+	addRange := widget.NewButtonWithIcon("添加", theme.ContentAddIcon(), func() {
+		start, end, err := minuteRange(rangeStart.Text, rangeEnd.Text)
+		if err != nil {
+			dialog.ShowError(err, app.MainWindow)
+			return
+		}
+		r := repository.WorkRange{Weekday: weekdaySelect.SelectedIndex(), Start: start, End: end}
+		if _, err = app.DB.InsertWorkRange(r); err != nil {
+			dialog.ShowError(err, app.MainWindow)
+			app.ErrorLog.Println(err)
+			return
+		}
+		refresh()
+	})
+	rangesTab := container.NewBorder(nil,
+		container.NewHBox(weekdaySelect, rangeStart, widget.NewLabel("-"), rangeEnd, addRange),
+		nil, nil, rangesTable)
+
+	// 休假和加班
+	exceptionsHeader := []string{"日期", "类型", "开始", "结束", "备注", "删除"}
+	exceptionsTable = widget.NewTable(
+		func() (int, int) {
+			return len(exceptions) + 1, len(exceptionsHeader)
+		},
+		func() fyne.CanvasObject {
+			return container.NewVBox(widget.NewLabel(""))
+		},
+		func(i widget.TableCellID, o fyne.CanvasObject) {
+			if i.Row == 0 {
+				o.(*fyne.Container).Objects = []fyne.CanvasObject{widget.NewLabel(exceptionsHeader[i.Col])}
+				return
+			}
+			e := exceptions[i.Row-1]
+			var w fyne.CanvasObject
+			switch i.Col {
+			case 0:
+				w = widget.NewLabel(e.Day)
+			case 1:
+				w = widget.NewLabel(exceptionKindText[e.Kind])
+			case 2:
+				w = widget.NewLabel(formatMinute(e.Start))
+			case 3:
+				w = widget.NewLabel(formatMinute(e.End))
+			case 4:
+				w = widget.NewLabel(e.Note)
+			case 5:
+				button := widget.NewButtonWithIcon("", theme.DeleteIcon(), func() {
+					if err := app.DB.DeleteScheduleException(e.ID); err != nil {
+						app.ErrorLog.Println(err)
+					}
+					refresh()
+				})
+				button.Importance = widget.HighImportance
+				w = button
+			}
+			o.(*fyne.Container).Objects = []fyne.CanvasObject{w}
+		})
+	for i, width := range []float32{110, 60, 70, 70, 200, 60} {
+		exceptionsTable.SetColumnWidth(i, width)
+	}
+
+	dayEntry := widget.NewEntry()
+	dayEntry.SetText(time.Now().Format("2006-01-02"))
+	dayEntry.Validator = dateValidator
+	kindSelect := widget.NewSelect([]string{"休假", "加班"}, nil)
+	kindSelect.SetSelected("休假")
+	exceptionStart := timeEntry(0)
+	exceptionEnd := timeEntry(24 * 60)
+	noteEntry := widget.NewEntry()
+	noteEntry.PlaceHolder = "备注"
+	addException := widget.NewButtonWithIcon("添加", theme.ContentAddIcon(), func() {
+		if err := dateValidator(dayEntry.Text); err != nil {
+			dialog.ShowError(err, app.MainWindow)
+			return
+		}
+		start, end, err := minuteRange(exceptionStart.Text, exceptionEnd.Text)
+		if err != nil {
+			dialog.ShowError(err, app.MainWindow)
+			return
+		}
+		e := repository.ScheduleException{
+			Day:   dayEntry.Text,
+			Kind:  textKey(exceptionKindText, kindSelect.Selected),
+			Start: start,
+			End:   end,
+			Note:  noteEntry.Text,
+		}
+		if _, err = app.DB.InsertScheduleException(e); err != nil {
+			dialog.ShowError(err, app.MainWindow)
+			app.ErrorLog.Println(err)
+			return
+		}
+		noteEntry.SetText("")
+		refresh()
+	})
+	exceptionsTab := container.NewBorder(nil,
+		container.NewBorder(nil, nil,
+			container.NewHBox(dayEntry, kindSelect, exceptionStart, widget.NewLabel("-"), exceptionEnd),
+			addException,
+			noteEntry),
+		nil, nil, exceptionsTable)
+
+	tabs := container.NewAppTabs(
+		container.NewTabItem("每周", rangesTab),
+		container.NewTabItem("休假/加班", exceptionsTab),
+	)
+
+	scheduleDialog := dialog.NewCustom("工作时间", "关闭", tabs, app.MainWindow)
+	scheduleDialog.Resize(fyne.Size{Width: 700, Height: 450})
+	scheduleDialog.Show()
+
+	return scheduleDialog
+}
+
+// timeEntry 时:分 格式的输入框
+func timeEntry(minute int) *widget.Entry {
+	entry := widget.NewEntry()
+	entry.SetText(formatMinute(minute))
+	entry.Validator = minuteValidator
+	return entry
+}
+
+// minuteRange 解析开始和结束时间，结束要晚于开始
+func minuteRange(startText, endText string) (int, int, error) {
+	start, err := parseMinute(startText)
+	if err != nil {
+		return 0, 0, err
+	}
+	end, err := parseMinute(endText)
+	if err != nil {
+		return 0, 0, err
+	}
+	if end <= start {
+		return 0, 0, errors.New("结束时间要晚于开始时间")
+	}
+	return start, end, nil
+}
+
+var reminderKindText = map[string]string{
+	repository.ReminderInterval: "间隔(分钟)",
+	repository.ReminderFixed:    "每天定时",
+	repository.ReminderCron:     "Cron",
+}
+
+// remindersDialog 编辑喝水、久坐等提醒
+func (app *Config) remindersDialog() dialog.Dialog {
+	var reminders []repository.Reminder
+	var table *widget.Table
+
+	refresh := func() {
+		all, err := app.DB.AllReminders()
+		if err != nil {
+			app.ErrorLog.Println(err)
+		}
+		reminders = all
+		if table != nil {
+			table.Refresh()
+		}
+	}
+	refresh()
+
+	header := []string{"启用", "名称", "内容", "方式", "规则", "仅工作时间", "删除"}
+	table = widget.NewTable(
+		func() (int, int) {
+			return len(reminders) + 1, len(header)
+		},
+		func() fyne.CanvasObject {
+			return container.NewVBox(widget.NewLabel(""))
+		},
+		func(i widget.TableCellID, o fyne.CanvasObject) {
+			if i.Row == 0 {
+				o.(*fyne.Container).Objects = []fyne.CanvasObject{widget.NewLabel(header[i.Col])}
+				return
+			}
+			r := reminders[i.Row-1]
+			var w fyne.CanvasObject
+			switch i.Col {
+			case 0:
+				check := widget.NewCheck("", nil)
+				check.SetChecked(r.Enabled)
+				check.OnChanged = func(enabled bool) {
+					if err := app.DB.SetReminderFlags(r.ID, enabled, r.WorkHoursOnly); err != nil {
+						app.ErrorLog.Println(err)
+					}
+					refresh()
+				}
+				w = check
+			case 1:
+				w = widget.NewLabel(r.Title)
+			case 2:
+				w = widget.NewLabel(r.Message)
+			case 3:
+				w = widget.NewLabel(reminderKindText[r.Kind])
+			case 4:
+				w = widget.NewLabel(r.Spec)
+			case 5:
+				check := widget.NewCheck("", nil)
+				check.SetChecked(r.WorkHoursOnly)
+				check.OnChanged = func(workHoursOnly bool) {
+					if err := app.DB.SetReminderFlags(r.ID, r.Enabled, workHoursOnly); err != nil {
+						app.ErrorLog.Println(err)
+					}
+					refresh()
+				}
+				w = check
+			case 6:
+				button := widget.NewButtonWithIcon("", theme.DeleteIcon(), func() {
+					if err := app.DB.DeleteReminder(r.ID); err != nil {
+						app.ErrorLog.Println(err)
+					}
+					refresh()
+				})
+				button.Importance = widget.HighImportance
+				w = button
+			}
+			o.(*fyne.Container).Objects = []fyne.CanvasObject{w}
+		})
+
+	for i, width := range []float32{50, 90, 200, 90, 110, 90, 60} {
+		table.SetColumnWidth(i, width)
+	}
+
+	// 新增提醒
+	titleEntry := widget.NewEntry()
+	titleEntry.PlaceHolder = "名称"
+	messageEntry := widget.NewEntry()
+	messageEntry.PlaceHolder = "提醒内容"
+	kindSelect := widget.NewSelect([]string{"间隔(分钟)", "每天定时", "Cron"}, nil)
+	specEntry := widget.NewEntry()
+	kindSelect.OnChanged = func(kind string) {
+		switch textKey(reminderKindText, kind) {
+		case repository.ReminderInterval:
+			specEntry.PlaceHolder = "30"
+		case repository.ReminderFixed:
+			specEntry.PlaceHolder = "10:00,15:30"
+		case repository.ReminderCron:
+			specEntry.PlaceHolder = "0 */2 * * 1-5"
+		}
+		specEntry.Refresh()
+	}
+	kindSelect.SetSelected("间隔(分钟)")
+	workHoursCheck := widget.NewCheck("仅工作时间", nil)
+	workHoursCheck.SetChecked(true)
+
+	addButton := widget.NewButtonWithIcon("添加", theme.ContentAddIcon(), func() {
+		kind := textKey(reminderKindText, kindSelect.Selected)
+		if titleEntry.Text == "" {
+			dialog.ShowError(errors.New("请填写名称"), app.MainWindow)
+			return
+		}
+		if _, err := parseReminderSpec(kind, specEntry.Text); err != nil {
+			dialog.ShowError(err, app.MainWindow)
+			return
+		}
+		r := repository.Reminder{
+			Title:         titleEntry.Text,
+			Message:       messageEntry.Text,
+			Kind:          kind,
+			Spec:          specEntry.Text,
+			Enabled:       true,
+			WorkHoursOnly: workHoursCheck.Checked,
+		}
+		if _, err := app.DB.InsertReminder(r); err != nil {
+			dialog.ShowError(err, app.MainWindow)
+			app.ErrorLog.Println(err)
+			return
+		}
+		titleEntry.SetText("")
+		messageEntry.SetText("")
+		specEntry.SetText("")
+		refresh()
+	})
+
+	addBar := container.NewVBox(
+		container.NewGridWithColumns(2, titleEntry, messageEntry),
+		container.NewBorder(nil, nil, kindSelect, container.NewHBox(workHoursCheck, addButton), specEntry),
+	)
+	content := container.NewBorder(nil, addBar, nil, nil, table)
+
+	remindersDialog := dialog.NewCustom("提醒", "关闭", content, app.MainWindow)
+	remindersDialog.Resize(fyne.Size{Width: 750, Height: 450})
+	remindersDialog.Show()
+
+	return remindersDialog
+}
+
+// textKey 根据中文名找到对应的值
+func textKey(texts map[string]string, text string) string {
+	for key, t := range texts {
+		if t == text {
+			return key
+		}
+	}
+	return ""
+}
+
+// intEntry 带初始值和校验的整数输入框
+func intEntry(value int, validator fyne.StringValidator) *widget.Entry {
+	entry := widget.NewEntry()
+	entry.SetText(strconv.Itoa(value))
+	entry.Validator = validator
+	return entry
+}
+
+// intRangeValidator 整数并且在 [min, max] 之间
+func intRangeValidator(min, max int) fyne.StringValidator {
+	return func(text string) error {
+		i, err := strconv.Atoi(text)
+		if err != nil {
+			return err
+		}
+		if i < min || i > max {
+			return fmt.Errorf("请输入 %d 到 %d 之间的整数", min, max)
+		}
+		return nil
+	}
+}
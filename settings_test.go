@@ -0,0 +1,52 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBoolSetting(t *testing.T) {
+	settings := map[string]string{"on": "1", "off": "0", "bad": "yes"}
+
+	if !boolSetting(settings, "on", false) {
+		t.Error("expected on")
+	}
+	if boolSetting(settings, "off", true) {
+		t.Error("expected off")
+	}
+	if !boolSetting(settings, "bad", true) || !boolSetting(settings, "missing", true) {
+		t.Error("expected default for bad or missing value")
+	}
+}
+
+func TestIntRangeValidator(t *testing.T) {
+	v := intRangeValidator(1, 60)
+
+	for _, text := range []string{"1", "30", "60"} {
+		if err := v(text); err != nil {
+			t.Errorf("%q should be valid: %s", text, err)
+		}
+	}
+	for _, text := range []string{"0", "61", "abc", ""} {
+		if err := v(text); err == nil {
+			t.Errorf("%q should be invalid", text)
+		}
+	}
+}
+
+func TestApp_loadFishRules_Notify(t *testing.T) {
+	_ = testApp.DB.SaveSetting(settingSampleInterval, "30")
+	_ = testApp.DB.SaveSetting(settingNotifyFish, "0")
+	testApp.loadFishRules()
+
+	if currentSampleInterval() != 30*time.Second {
+		t.Error("sample interval not loaded, got", currentSampleInterval())
+	}
+	if notifyFish {
+		t.Error("notify fish should be off")
+	}
+
+	_ = testApp.DB.SaveSetting(settingSampleInterval, "20")
+	_ = testApp.DB.SaveSetting(settingNotifyFish, "1")
+	testApp.loadFishRules()
+}
@@ -0,0 +1,27 @@
+package main
+
+import (
+	"NoFish/repository"
+	"fyne.io/fyne/v2/test"
+	"log"
+	"os"
+	"testing"
+)
+
+var testApp Config
+
+// testNotifier 记录测试中发出的通知
+var testNotifier = &RecordingNotifier{}
+
+func TestMain(m *testing.M) {
+	a := test.NewApp()
+	testApp.App = a
+	testApp.MainWindow = a.NewWindow("")
+	testApp.InfoLog = log.New(os.Stdout, "INFO\t", log.Ldate|log.Ltime)
+	testApp.ErrorLog = log.New(os.Stdout, "ERROR\t", log.Ldate|log.Ltime|log.Lshortfile)
+	testApp.DB = repository.NewTestRepository()
+	_ = testApp.DB.Migrate()
+	testApp.Notifier = testNotifier
+	testApp.Focus = NewFocusMode(&testApp)
+	os.Exit(m.Run())
+}
@@ -0,0 +1,292 @@
+package main
+
+import (
+	"NoFish/repository"
+	"fmt"
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"time"
+)
+
+// 统计粒度
+const (
+	StatsDay   = "day"
+	StatsWeek  = "week"
+	StatsMonth = "month"
+)
+
+var statsRangeText = map[string]string{
+	StatsDay:   "按天",
+	StatsWeek:  "按周",
+	StatsMonth: "按月",
+}
+
+// 每种粒度显示最近多少段
+var statsPeriodCount = map[string]int{
+	StatsDay:   14,
+	StatsWeek:  12,
+	StatsMonth: 12,
+}
+
+// 统计图的大小，单位像素
+const (
+	statsChartWidth  = 360
+	statsChartHeight = 90
+)
+
+// StatsPeriod 一段时间（一天、一周或一个月）的统计，时间范围是 [From, To)
+type StatsPeriod struct {
+	Label string
+	From  time.Time
+	To    time.Time
+
+	FishCount      int
+	TasksCompleted int
+	// 工作和摸鱼窗口的时长，来自活动日志，单位秒
+	ProductiveSeconds  int64
+	DistractingSeconds int64
+	// 获得和花掉（兑换、扣分）的积分，都是正数
+	PointsEarned int
+	PointsSpent  int
+}
+
+// statsPeriods now 所在的一段和之前的若干段，从早到晚，周从周一开始
+func statsPeriods(kind string, now time.Time) []StatsPeriod {
+	day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	count := statsPeriodCount[kind]
+
+	periods := make([]StatsPeriod, count)
+	for i := 0; i < count; i++ {
+		back := count - 1 - i
+		var p StatsPeriod
+		switch kind {
+		case StatsWeek:
+			monday := day.AddDate(0, 0, -(int(day.Weekday())+6)%7)
+			p.From = monday.AddDate(0, 0, -7*back)
+			p.To = p.From.AddDate(0, 0, 7)
+			p.Label = p.From.Format("01-02")
+		case StatsMonth:
+			p.From = time.Date(day.Year(), day.Month()-time.Month(back), 1, 0, 0, 0, 0, day.Location())
+			p.To = p.From.AddDate(0, 1, 0)
+			p.Label = p.From.Format("2006-01")
+		default:
+			p.From = day.AddDate(0, 0, -back)
+			p.To = p.From.AddDate(0, 0, 1)
+			p.Label = p.From.Format("01-02")
+		}
+		periods[i] = p
+	}
+	return periods
+}
+
+// periodIndex t 落在哪一段，都不在返回 -1
+func periodIndex(periods []StatsPeriod, t time.Time) int {
+	for i, p := range periods {
+		if !t.Before(p.From) && t.Before(p.To) {
+			return i
+		}
+	}
+	return -1
+}
+
+// loadStats 按粒度汇总今日概况、活动日志和积分流水
+func (app *Config) loadStats(kind string, now time.Time) ([]StatsPeriod, error) {
+	periods := statsPeriods(kind, now)
+	from, to := periods[0].From, periods[len(periods)-1].To
+
+	summaries, err := app.DB.SummariesBetween(from.Format("2006-01-02"), to.AddDate(0, 0, -1).Format("2006-01-02"))
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range summaries {
+		day, err := time.ParseInLocation("2006-01-02", s.Day, now.Location())
+		if err != nil {
+			continue
+		}
+		if i := periodIndex(periods, day); i >= 0 {
+			periods[i].FishCount += int(s.FishCount)
+		}
+	}
+
+	// 完成任务数以任务的完成时间为准，和报告一致
+	tasks, err := app.DB.AllTasks()
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range tasks {
+		if !t.Completed {
+			continue
+		}
+		if i := periodIndex(periods, t.CompletedAt); i >= 0 {
+			periods[i].TasksCompleted++
+		}
+	}
+
+	activities, err := app.DB.ActivitiesBetween(from, to)
+	if err != nil {
+		return nil, err
+	}
+	// 跨过零点或周、月边界的活动按时间拆到各段，和报告、热力图一致
+	for _, a := range activities {
+		for i := range periods {
+			start, end := a.StartedAt, a.EndedAt
+			if start.Before(periods[i].From) {
+				start = periods[i].From
+			}
+			if end.After(periods[i].To) {
+				end = periods[i].To
+			}
+			if !start.Before(end) {
+				continue
+			}
+			seconds := int64(end.Sub(start).Seconds())
+			switch a.Category {
+			case repository.CategoryProductive:
+				periods[i].ProductiveSeconds += seconds
+			case repository.CategoryDistracting:
+				periods[i].DistractingSeconds += seconds
+			}
+		}
+	}
+
+	entries, err := app.DB.AllLedgerEntries()
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range entries {
+		i := periodIndex(periods, e.CreatedAt)
+		if i < 0 {
+			continue
+		}
+		if e.Points > 0 {
+			periods[i].PointsEarned += e.Points
+		} else {
+			periods[i].PointsSpent -= e.Points
+		}
+	}
+
+	return periods, nil
+}
+
+// statsChart 统计面板里的一张图
+type statsChart struct {
+	Title string
+	Chart BarChart
+}
+
+// statsCharts 摸鱼次数、工作和摸鱼时长、完成任务数、积分收支四张图
+func statsCharts(periods []StatsPeriod) []statsChart {
+	labels := make([]string, len(periods))
+	fish := make([]int, len(periods))
+	productive := make([]int, len(periods))
+	distracting := make([]int, len(periods))
+	tasks := make([]int, len(periods))
+	earned := make([]int, len(periods))
+	spent := make([]int, len(periods))
+	for i, p := range periods {
+		labels[i] = p.Label
+		fish[i] = p.FishCount
+		productive[i] = int(p.ProductiveSeconds / 60)
+		distracting[i] = int(p.DistractingSeconds / 60)
+		tasks[i] = p.TasksCompleted
+		earned[i] = p.PointsEarned
+		spent[i] = p.PointsSpent
+	}
+
+	return []statsChart{
+		{"摸鱼次数", BarChart{Labels: labels, Series: []ChartSeries{
+			{Name: "摸鱼", Color: colorDistracting, Values: fish},
+		}}},
+		{"时长(分钟)", BarChart{Labels: labels, Series: []ChartSeries{
+			{Name: "工作", Color: colorProductive, Values: productive},
+			{Name: "摸鱼", Color: colorDistracting, Values: distracting},
+		}}},
+		{"完成任务", BarChart{Labels: labels, Series: []ChartSeries{
+			{Name: "任务", Color: colorTasks, Values: tasks},
+		}}},
+		{"积分", BarChart{Labels: labels, Series: []ChartSeries{
+			{Name: "获得", Color: colorEarned, Values: earned},
+			{Name: "花费", Color: colorSpent, Values: spent},
+		}}},
+	}
+}
+
+// statsTab 统计面板：按天、按周、按月的趋势图和按时段的热力图
+func (app *Config) statsTab() *fyne.Container {
+	app.StatsRange = StatsDay
+	app.StatsCharts = container.NewGridWithColumns(2)
+
+	rangeSelect := widget.NewRadioGroup([]string{statsRangeText[StatsDay], statsRangeText[StatsWeek], statsRangeText[StatsMonth]}, func(selected string) {
+		if kind := textKey(statsRangeText, selected); kind != "" {
+			app.StatsRange = kind
+		}
+		app.refreshStats()
+	})
+	rangeSelect.Horizontal = true
+	// 选中时会画图
+	rangeSelect.SetSelected(statsRangeText[app.StatsRange])
+	refreshButton := widget.NewButtonWithIcon("刷新", theme.ViewRefreshIcon(), func() {
+		app.refreshStats()
+	})
+
+	top := container.NewBorder(nil, nil, rangeSelect, refreshButton)
+	trends := container.NewBorder(top, nil, nil, nil, app.StatsCharts)
+
+	tabs := container.NewAppTabs(
+		container.NewTabItem("趋势", trends),
+		container.NewTabItem("时段热力图", app.heatmapView()),
+	)
+	return container.NewBorder(nil, nil, nil, nil, tabs)
+}
+
+// refreshStats 重新汇总并画图
+func (app *Config) refreshStats() {
+	if app.StatsCharts == nil {
+		return
+	}
+
+	periods, err := app.loadStats(app.StatsRange, time.Now())
+	if err != nil {
+		app.ErrorLog.Println(err)
+		return
+	}
+
+	var cards []fyne.CanvasObject
+	for _, c := range statsCharts(periods) {
+		cards = append(cards, chartCard(c))
+	}
+	app.StatsCharts.Objects = cards
+	app.StatsCharts.Refresh()
+}
+
+// chartCard 图片加上标题、图例、最大值和首尾标签
+func chartCard(c statsChart) fyne.CanvasObject {
+	img := canvas.NewImageFromImage(c.Chart.Render(statsChartWidth, statsChartHeight))
+	img.FillMode = canvas.ImageFillStretch
+	img.SetMinSize(fyne.NewSize(statsChartWidth, statsChartHeight))
+
+	legend := container.NewHBox(widget.NewLabelWithStyle(c.Title, fyne.TextAlignLeading, fyne.TextStyle{Bold: true}))
+	if len(c.Chart.Series) > 1 {
+		for _, s := range c.Chart.Series {
+			swatch := canvas.NewRectangle(s.Color)
+			swatch.SetMinSize(fyne.NewSize(10, 10))
+			legend.Add(container.NewCenter(swatch))
+			legend.Add(widget.NewLabel(s.Name))
+		}
+	}
+	legend.Add(layout.NewSpacer())
+	legend.Add(widget.NewLabel(fmt.Sprintf("最大 %d", c.Chart.Max())))
+
+	axis := container.NewHBox()
+	if n := len(c.Chart.Labels); n > 0 {
+		axis.Add(widget.NewLabel(c.Chart.Labels[0]))
+		axis.Add(layout.NewSpacer())
+		axis.Add(widget.NewLabel(c.Chart.Labels[n-1]))
+	}
+
+	return container.NewVBox(legend, img, axis)
+}
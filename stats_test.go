@@ -0,0 +1,167 @@
+package main
+
+import (
+	"NoFish/repository"
+	"testing"
+	"time"
+)
+
+func TestStatsPeriods(t *testing.T) {
+	// 2023-04-12 是周三
+	now := time.Date(2023, 4, 12, 15, 0, 0, 0, time.Local)
+
+	days := statsPeriods(StatsDay, now)
+	if len(days) != 14 || days[13].Label != "04-12" || !days[0].From.Equal(time.Date(2023, 3, 30, 0, 0, 0, 0, time.Local)) {
+		t.Error("wrong day periods:", days[0], days[13])
+	}
+
+	weeks := statsPeriods(StatsWeek, now)
+	last := weeks[len(weeks)-1]
+	if len(weeks) != 12 || !last.From.Equal(time.Date(2023, 4, 10, 0, 0, 0, 0, time.Local)) || !last.To.Equal(time.Date(2023, 4, 17, 0, 0, 0, 0, time.Local)) {
+		t.Error("weeks should start on monday:", last)
+	}
+	// 周日也算在本周
+	sunday := statsPeriods(StatsWeek, time.Date(2023, 4, 16, 9, 0, 0, 0, time.Local))
+	if !sunday[len(sunday)-1].From.Equal(last.From) {
+		t.Error("sunday should belong to the week starting monday:", sunday[len(sunday)-1])
+	}
+
+	months := statsPeriods(StatsMonth, now)
+	if len(months) != 12 || months[0].Label != "2022-05" || months[11].Label != "2023-04" || !months[11].To.Equal(time.Date(2023, 5, 1, 0, 0, 0, 0, time.Local)) {
+		t.Error("wrong month periods:", months[0], months[11])
+	}
+}
+
+func TestApp_loadStats(t *testing.T) {
+	restoreBalance(t)
+	day := time.Date(2023, 4, 12, 0, 0, 0, 0, time.Local)
+
+	_ = testApp.DB.IncrementSummary("2023-04-11", 2, 0, 0)
+	_ = testApp.DB.IncrementSummary("2023-04-12", 3, 0, 0)
+	_, _ = testApp.DB.RecordActivity(repository.Activity{StartedAt: day.Add(9 * time.Hour), EndedAt: day.Add(10 * time.Hour), Title: "微信读书", Category: repository.CategoryProductive, Samples: 1})
+	_, _ = testApp.DB.RecordActivity(repository.Activity{StartedAt: day.Add(11 * time.Hour), EndedAt: day.Add(11*time.Hour + 20*time.Minute), Title: "知乎", Category: repository.CategoryDistracting, Samples: 1})
+	_, _ = testApp.DB.RecordActivity(repository.Activity{StartedAt: day.Add(12 * time.Hour), EndedAt: day.Add(13 * time.Hour), Title: "午饭", Category: repository.CategoryIdle, Samples: 1})
+	_, _ = testApp.DB.InsertLedgerEntry(repository.LedgerEntry{Kind: repository.LedgerEarned, Points: 5, CreatedAt: day.Add(9 * time.Hour)})
+	_, _ = testApp.DB.InsertLedgerEntry(repository.LedgerEntry{Kind: repository.LedgerSpent, Points: -3, CreatedAt: day.Add(18 * time.Hour)})
+
+	periods, err := testApp.loadStats(StatsDay, day.Add(20*time.Hour))
+	if err != nil {
+		t.Fatal("load stats failed:", err)
+	}
+	today, yesterday := periods[13], periods[12]
+	if today.FishCount != 3 || yesterday.FishCount != 2 {
+		t.Error("summary not aggregated:", yesterday, today)
+	}
+	if today.ProductiveSeconds != 3600 || today.DistractingSeconds != 1200 {
+		t.Error("activity time not aggregated:", today)
+	}
+	if today.PointsEarned != 5 || today.PointsSpent != 3 {
+		t.Error("ledger not aggregated:", today)
+	}
+
+	// 按月汇总在同一段里
+	months, _ := testApp.loadStats(StatsMonth, day)
+	april := months[11]
+	if april.FishCount != 5 || april.ProductiveSeconds != 3600 {
+		t.Error("wrong month stats:", april)
+	}
+
+	charts := statsCharts(periods)
+	if len(charts) != 4 || charts[1].Chart.Series[0].Values[13] != 60 || charts[1].Chart.Series[1].Values[13] != 20 {
+		t.Error("wrong charts:", charts)
+	}
+}
+
+func TestApp_loadStats_AcrossMidnight(t *testing.T) {
+	day := time.Date(2022, 8, 16, 0, 0, 0, 0, time.Local)
+	// 23:30 到 00:30，前后两天各算 30 分钟；从窗口第一天之前开始的也算进第一天
+	_, _ = testApp.DB.RecordActivity(repository.Activity{StartedAt: day.Add(-30 * time.Minute), EndedAt: day.Add(30 * time.Minute), Title: "写代码", Category: repository.CategoryProductive, Samples: 1})
+
+	periods, err := testApp.loadStats(StatsDay, day)
+	if err != nil {
+		t.Fatal("load stats failed:", err)
+	}
+	if today, yesterday := periods[13], periods[12]; today.ProductiveSeconds != 1800 || yesterday.ProductiveSeconds != 1800 {
+		t.Error("activity not split at midnight:", yesterday, today)
+	}
+
+	periods, _ = testApp.loadStats(StatsDay, day.AddDate(0, 0, 13))
+	if periods[0].ProductiveSeconds != 1800 {
+		t.Error("activity starting before the first period dropped:", periods[0])
+	}
+}
+
+func TestApp_loadStats_TasksCompleted(t *testing.T) {
+	restoreBalance(t)
+	now := time.Now()
+	before, err := testApp.loadStats(StatsDay, now)
+	if err != nil {
+		t.Fatal("load stats failed:", err)
+	}
+
+	// 通过完成任务产生数据，清理时删除任务并撤销今日概况上的累加
+	for _, name := range []string{"统计任务一", "统计任务二"} {
+		task, _ := testApp.DB.InsertTask(repository.Task{Name: name, DueDate: now, Points: 1})
+		done, err := testApp.DB.CompleteTask(task.ID)
+		if err != nil {
+			t.Fatal("complete task failed:", err)
+		}
+		t.Cleanup(func() {
+			_ = testApp.DB.DeleteTask(done.ID)
+			_ = testApp.DB.IncrementSummary(done.CompletedAt.Format("2006-01-02"), 0, -1, -done.Points)
+		})
+	}
+
+	after, err := testApp.loadStats(StatsDay, now)
+	if err != nil {
+		t.Fatal("load stats failed:", err)
+	}
+	if got := after[13].TasksCompleted - before[13].TasksCompleted; got != 2 {
+		t.Error("completed tasks not counted by completion time, got", got)
+	}
+}
+
+func TestBarChart_Render(t *testing.T) {
+	chart := BarChart{
+		Labels: []string{"a", "b"},
+		Series: []ChartSeries{
+			{Name: "工作", Color: colorProductive, Values: []int{10, 0}},
+			{Name: "摸鱼", Color: colorDistracting, Values: []int{5, 20}},
+		},
+	}
+	if chart.Max() != 20 {
+		t.Error("wrong max:", chart.Max())
+	}
+
+	img := chart.Render(208, 108)
+	if img.Bounds().Dx() != 208 || img.Bounds().Dy() != 108 {
+		t.Fatal("wrong image size:", img.Bounds())
+	}
+	// 绘图区 8..200 x 8..100，每组 96 像素，两边留 16，柱宽 32
+	if c := img.RGBAAt(40, 99); c != colorProductive {
+		t.Error("expected productive bar in first group, got", c)
+	}
+	if c := img.RGBAAt(40, 40); c != chartBackground && c != chartGrid {
+		t.Error("productive bar taller than half height, got", c)
+	}
+	if c := img.RGBAAt(104+16+32+1, 10); c != colorDistracting {
+		t.Error("expected full height distracting bar in second group, got", c)
+	}
+	if c := img.RGBAAt(104+16+1, 99); c == colorProductive {
+		t.Error("zero value drawn as a bar")
+	}
+}
+
+func TestApp_statsTab(t *testing.T) {
+	tab := testApp.statsTab()
+	defer func() { testApp.StatsCharts = nil }()
+
+	if tab == nil || len(testApp.StatsCharts.Objects) != 4 {
+		t.Fatal("expected 4 charts in stats tab")
+	}
+	testApp.StatsRange = StatsMonth
+	testApp.refreshStats()
+	if len(testApp.StatsCharts.Objects) != 4 {
+		t.Error("charts not redrawn for month range")
+	}
+}
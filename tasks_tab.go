@@ -2,6 +2,7 @@ package main
 
 import (
 	"NoFish/repository"
+	"fmt"
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/container"
 	"fyne.io/fyne/v2/dialog"
@@ -10,6 +11,34 @@ import (
 	"strconv"
 )
 
+// 当前任务，番茄钟和工作窗口时长都记到这个任务上，0 表示没有
+var activeTask int64
+
+// currentActiveTask 当前任务的 ID
+func currentActiveTask() int64 {
+	rulesLock.RLock()
+	defer rulesLock.RUnlock()
+
+	return activeTask
+}
+
+// setActiveTask 设置当前任务，id 为 0 表示取消，正在进行的番茄钟也改为记到这个任务上
+func (app *Config) setActiveTask(id int64) {
+	err := app.DB.SaveSetting(settingActiveTask, strconv.FormatInt(id, 10))
+	if err != nil {
+		app.ErrorLog.Println(err)
+		return
+	}
+
+	rulesLock.Lock()
+	activeTask = id
+	rulesLock.Unlock()
+
+	if app.Pomodoro != nil {
+		app.Pomodoro.SetTask(id)
+	}
+}
+
 func (app *Config) tasksTab() *fyne.Container {
 	app.Tasks = app.getTaskSlice()
 
@@ -20,6 +49,16 @@ func (app *Config) tasksTab() *fyne.Container {
 
 }
 
+// completedTasksTab 已完成任务
+func (app *Config) completedTasksTab() *fyne.Container {
+	app.CompletedTasks = app.getCompletedTaskSlice()
+
+	app.CompletedTasksTable = app.getCompletedTasksTable()
+
+	completedContainer := container.NewBorder(nil, nil, nil, nil, container.NewAdaptiveGrid(1, app.CompletedTasksTable))
+	return completedContainer
+}
+
 func (app *Config) getTasksTable() *widget.Table {
 
 	table := widget.NewTable(
@@ -44,24 +83,95 @@ func (app *Config) getTasksTable() *widget.Table {
 							if err != nil {
 								app.ErrorLog.Println(err)
 							}
+							if int64(id) == currentActiveTask() {
+								app.setActiveTask(0)
+							}
 						}
 						app.refreshTasksTable()
 					}, app.MainWindow)
 				})
 				w.Importance = widget.HighImportance
 				o.(*fyne.Container).Objects = []fyne.CanvasObject{w}
+			} else if i.Col == (len(app.Tasks[0])-2) && i.Row != 0 {
+				w := widget.NewButtonWithIcon("完成", theme.ConfirmIcon(), func() {
+					app.completeTask(i.Row)
+				})
+				w.Importance = widget.HighImportance
+				o.(*fyne.Container).Objects = []fyne.CanvasObject{w}
+			} else if i.Col == (len(app.Tasks[0])-3) && i.Row != 0 {
+				// 设为当前任务，再点一次取消
+				id, _ := strconv.ParseInt(app.Tasks[i.Row][0].(string), 10, 64)
+				w := widget.NewButtonWithIcon("专注", theme.MediaPlayIcon(), func() {
+					if id == currentActiveTask() {
+						app.setActiveTask(0)
+					} else {
+						app.setActiveTask(id)
+					}
+					app.refreshTasksTable()
+				})
+				if id == currentActiveTask() {
+					w.SetText("当前")
+					w.Importance = widget.HighImportance
+				}
+				o.(*fyne.Container).Objects = []fyne.CanvasObject{w}
 			} else {
 				o.(*fyne.Container).Objects = []fyne.CanvasObject{widget.NewLabel(app.Tasks[i.Row][i.Col].(string))}
 			}
 		})
 
-	colWidths := []float32{30, 100, 350, 80, 70, 70, 60}
+	colWidths := []float32{30, 90, 150, 80, 50, 40, 50, 50, 40, 70, 70, 70}
+	for i := 0; i < len(colWidths); i++ {
+		table.SetColumnWidth(i, colWidths[i])
+	}
+	return table
+}
+
+func (app *Config) getCompletedTasksTable() *widget.Table {
+
+	table := widget.NewTable(
+		func() (int, int) {
+			return len(app.CompletedTasks), len(app.CompletedTasks[0])
+		},
+		func() fyne.CanvasObject {
+			ctr := container.NewVBox(widget.NewLabel(""))
+			return ctr
+		},
+		func(i widget.TableCellID, o fyne.CanvasObject) {
+			o.(*fyne.Container).Objects = []fyne.CanvasObject{widget.NewLabel(app.CompletedTasks[i.Row][i.Col].(string))}
+		})
+
+	colWidths := []float32{30, 100, 250, 140, 50, 50, 50, 50}
 	for i := 0; i < len(colWidths); i++ {
 		table.SetColumnWidth(i, colWidths[i])
 	}
 	return table
 }
 
+// completeTask 完成任务并获得积分
+func (app *Config) completeTask(row int) {
+	id, _ := strconv.Atoi(app.Tasks[row][0].(string))
+	name := app.Tasks[row][1].(string)
+	points := app.Tasks[row][5].(string)
+
+	dialog.ShowConfirm("完成任务", fmt.Sprintf("确定已完成「%s」？将获得 %s 积分", name, points), func(ok bool) {
+		if !ok {
+			return
+		}
+		_, err := app.DB.CompleteTask(int64(id))
+		if err != nil {
+			dialog.ShowError(err, app.MainWindow)
+			app.ErrorLog.Println(err)
+			return
+		}
+		if int64(id) == currentActiveTask() {
+			app.setActiveTask(0)
+		}
+
+		app.refreshTasksTable()
+		app.refreshSum()
+	}, app.MainWindow)
+}
+
 func (app *Config) getTaskSlice() [][]interface{} {
 	var slice [][]interface{}
 
@@ -70,29 +180,63 @@ func (app *Config) getTaskSlice() [][]interface{} {
 		app.ErrorLog.Println(err)
 	}
 
-	slice = append(slice, []interface{}{"ID", "名字", "描述", "截止日期", "优先级", "积分", "删除"})
+	pomodoros := app.pomodoroCounts()
+
+	slice = append(slice, []interface{}{"ID", "名字", "描述", "截止日期", "优先级", "积分", "预估", "实际", "番茄", "专注", "完成", "删除"})
 
 	for _, x := range tasks {
+		if x.Completed {
+			continue
+		}
 		var currentRow []interface{}
 		currentRow = append(currentRow, strconv.FormatInt(x.ID, 10))
 		currentRow = append(currentRow, x.Name)
 		currentRow = append(currentRow, x.Description)
 		currentRow = append(currentRow, x.DueDate.Format("2006-01-02"))
-		switch int64(x.Priority) {
-		case 1:
-			currentRow = append(currentRow, "高")
-		case 2:
-			currentRow = append(currentRow, "中")
-		case 3:
-			currentRow = append(currentRow, "低")
-		}
+		currentRow = append(currentRow, priorityText(x.Priority))
 		currentRow = append(currentRow, strconv.FormatInt(int64(x.Points), 10))
+		currentRow = append(currentRow, estimateText(x.EstimateMinutes))
+		currentRow = append(currentRow, spentText(x.SpentSeconds))
+		currentRow = append(currentRow, strconv.Itoa(pomodoros[x.ID]))
+		currentRow = append(currentRow, widget.NewButtonWithIcon("专注", theme.MediaPlayIcon(), func() {}))
+		currentRow = append(currentRow, widget.NewButtonWithIcon("完成", theme.ConfirmIcon(), func() {}))
 		currentRow = append(currentRow, widget.NewButtonWithIcon("删除", theme.DeleteIcon(), func() {}))
 		slice = append(slice, currentRow)
 	}
 	return slice
 }
 
+// getCompletedTaskSlice 已完成的任务
+func (app *Config) getCompletedTaskSlice() [][]interface{} {
+	var slice [][]interface{}
+
+	tasks, err := app.currentTasks()
+	if err != nil {
+		app.ErrorLog.Println(err)
+	}
+
+	pomodoros := app.pomodoroCounts()
+
+	slice = append(slice, []interface{}{"ID", "名字", "描述", "完成时间", "积分", "预估", "实际", "番茄"})
+
+	for _, x := range tasks {
+		if !x.Completed {
+			continue
+		}
+		var currentRow []interface{}
+		currentRow = append(currentRow, strconv.FormatInt(x.ID, 10))
+		currentRow = append(currentRow, x.Name)
+		currentRow = append(currentRow, x.Description)
+		currentRow = append(currentRow, x.CompletedAt.Format("2006-01-02 15:04"))
+		currentRow = append(currentRow, strconv.FormatInt(int64(x.Points), 10))
+		currentRow = append(currentRow, estimateText(x.EstimateMinutes))
+		currentRow = append(currentRow, spentText(x.SpentSeconds))
+		currentRow = append(currentRow, strconv.Itoa(pomodoros[x.ID]))
+		slice = append(slice, currentRow)
+	}
+	return slice
+}
+
 func (app *Config) currentTasks() ([]repository.Task, error) {
 	tasks, err := app.DB.AllTasks()
 	if err != nil {
@@ -101,3 +245,27 @@ func (app *Config) currentTasks() ([]repository.Task, error) {
 	}
 	return tasks, nil
 }
+
+// pomodoroCounts 每个任务完成的番茄钟个数
+func (app *Config) pomodoroCounts() map[int64]int {
+	counts, err := app.DB.PomodoroCountByTask()
+	if err != nil {
+		app.ErrorLog.Println(err)
+		return map[int64]int{}
+	}
+	return counts
+}
+
+// estimateText 预估用时显示为 时:分，没有预估显示 -
+func estimateText(minutes int) string {
+	if minutes <= 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%d:%02d", minutes/60, minutes%60)
+}
+
+// spentText 实际用时显示为 时:分
+func spentText(seconds int64) string {
+	minutes := int(seconds / 60)
+	return fmt.Sprintf("%d:%02d", minutes/60, minutes%60)
+}
@@ -51,6 +51,10 @@ func (app *Config) addTaskDialog() dialog.Dialog {
 	// 任务积分
 	taskScoreEntry := widget.NewEntry()
 	taskScoreEntry.Validator = isIntValidator
+	// 预估用时，可以不填
+	taskEstimateEntry := widget.NewEntry()
+	taskEstimateEntry.PlaceHolder = "分钟，可不填"
+	taskEstimateEntry.Validator = optionalIntValidator
 	// 任务类型
 	taskTypeEntry := widget.NewSelect([]string{"长期", "短期"}, func(s string) {
 	})
@@ -68,6 +72,7 @@ func (app *Config) addTaskDialog() dialog.Dialog {
 			{Text: "描述", Widget: taskDescEntry},
 			{Text: "截止时间", Widget: taskDeadlineEntry},
 			{Text: "积分", Widget: taskScoreEntry},
+			{Text: "预估用时", Widget: taskEstimateEntry},
 			{Text: "类型", Widget: taskTypeEntry},
 			{Text: "优先级", Widget: taskPriorityEntry},
 		},
@@ -78,6 +83,7 @@ func (app *Config) addTaskDialog() dialog.Dialog {
 				desc := taskDescEntry.Text
 				deadline, _ := time.Parse("2006-01-02", taskDeadlineEntry.Text)
 				score, _ := strconv.Atoi(taskScoreEntry.Text)
+				estimate, _ := strconv.Atoi(taskEstimateEntry.Text)
 				taskType := taskTypeEntry.Selected
 				taskTypeInt := 0
 				if taskType == "长期" {
@@ -96,14 +102,15 @@ func (app *Config) addTaskDialog() dialog.Dialog {
 				}
 				// 保存到数据库
 				_, err := app.DB.InsertTask(repository.Task{
-					ID:          0,
-					Name:        name,
-					Description: desc,
-					DueDate:     deadline,
-					Completed:   false,
-					Points:      score,
-					IsLongTerm:  taskTypeInt,
-					Priority:    priorityInt,
+					ID:              0,
+					Name:            name,
+					Description:     desc,
+					DueDate:         deadline,
+					Completed:       false,
+					Points:          score,
+					IsLongTerm:      taskTypeInt,
+					Priority:        priorityInt,
+					EstimateMinutes: estimate,
 				})
 				if err != nil {
 					dialog.ShowError(err, app.MainWindow)
@@ -178,10 +185,6 @@ func (app *Config) addPrizeDialog() dialog.Dialog {
 	return addForm
 }
 
-func (app *Config) setupDialog() dialog.Dialog {
-	return nil
-}
-
 func isIntValidator(text string) error {
 	_, err := strconv.Atoi(text)
 	if err != nil {
@@ -190,6 +193,14 @@ func isIntValidator(text string) error {
 	return nil
 }
 
+// optionalIntValidator 可以不填，填了必须是整数
+func optionalIntValidator(text string) error {
+	if text == "" {
+		return nil
+	}
+	return isIntValidator(text)
+}
+
 func isFloatValidator(text string) error {
 	_, err := strconv.ParseFloat(text, 32)
 	if err != nil {
@@ -12,30 +12,38 @@ import (
 // makeUI 创建UI
 func (app *Config) makeUI() {
 	fishCount, finishCount, prizeCount := app.getSum()
+	productiveTime, neutralTime, distractingTime := app.getTimeSum()
 
 	// 创建一个容器
-	summary := container.NewGridWithColumns(3, fishCount, finishCount, prizeCount)
+	summary := container.NewGridWithColumns(3, fishCount, finishCount, prizeCount, productiveTime, neutralTime, distractingTime)
 	app.Summary = summary
+	pomodoroBar := app.pomodoroBar()
+	focusBar := app.focusBar()
 	// 创建工具栏,绑定到主窗口上
 	toolBar := app.getToolBar()
 	app.ToolBar = toolBar
 	tasksTabContent := app.tasksTab()
+	completedTab := app.completedTasksTab()
 	holdingsTab := app.prizesTab()
-	imgTab := app.imgTab()
+	statsTab := app.statsTab()
+	notificationsTab := app.notificationsTab()
 
 	// 创建标签页
 	tabs := container.NewAppTabs(
 		container.NewTabItemWithIcon("当前任务", theme.HomeIcon(), tasksTabContent),
-		container.NewTabItemWithIcon("任务设置", theme.InfoIcon(), imgTab),
+		container.NewTabItemWithIcon("已完成", theme.ConfirmIcon(), completedTab),
+		container.NewTabItemWithIcon("统计", theme.GridIcon(), statsTab),
 		container.NewTabItemWithIcon("奖品区域", theme.InfoIcon(), holdingsTab),
+		container.NewTabItemWithIcon("通知记录", theme.MailComposeIcon(), notificationsTab),
 	)
 	tabs.SetTabLocation(container.TabLocationTop)
 
 	// add container to window
 
-	finalContent := container.NewVBox(summary, toolBar, tabs)
+	finalContent := container.NewVBox(summary, pomodoroBar, focusBar, toolBar, tabs)
 
 	app.MainWindow.SetContent(finalContent)
+	app.MainWindow.SetMainMenu(fyne.NewMainMenu(app.reportMenu(), app.dataMenu()))
 
 	// 定时刷新总览 1分钟1次
 	go func() {
@@ -49,9 +57,13 @@ func (app *Config) makeUI() {
 func (app *Config) getSum() (*canvas.Text, *canvas.Text, *canvas.Text) {
 	var fishCount, finishCount, prizeCount *canvas.Text
 
-	fishCount = canvas.NewText(fmt.Sprintf("今日摸鱼次数: %d ", myApp.FishCount), nil)
-	finishCount = canvas.NewText(fmt.Sprintf("今日完成数: %d ", myApp.FinishCount), nil)
-	prizeCount = canvas.NewText(fmt.Sprintf("当前积分数: %d ", myApp.PrizeCount), nil)
+	// 次数以数据库中的今日概况为准，积分以流水合计为准
+	app.loadTodaySummary()
+	app.PrizeCount = app.currentBalance()
+
+	fishCount = canvas.NewText(fmt.Sprintf("今日摸鱼次数: %d ", app.FishCount), nil)
+	finishCount = canvas.NewText(fmt.Sprintf("今日完成数: %d ", app.FinishCount), nil)
+	prizeCount = canvas.NewText(fmt.Sprintf("当前积分数: %d ", app.PrizeCount), nil)
 	fishCount.TextSize, finishCount.TextSize, prizeCount.TextSize = 18, 18, 18
 
 	fishCount.Alignment = fyne.TextAlignLeading
@@ -60,12 +72,52 @@ func (app *Config) getSum() (*canvas.Text, *canvas.Text, *canvas.Text) {
 	return fishCount, finishCount, prizeCount
 }
 
+// getTimeSum 获取今日各分类时长，在 getSum 之后调用
+func (app *Config) getTimeSum() (*canvas.Text, *canvas.Text, *canvas.Text) {
+	var productiveTime, neutralTime, distractingTime *canvas.Text
+
+	productiveTime = canvas.NewText(fmt.Sprintf("工作: %d 分钟", int(app.ProductiveTime.Minutes())), nil)
+	neutralTime = canvas.NewText(fmt.Sprintf("其他: %d 分钟", int(app.NeutralTime.Minutes())), nil)
+	distractingTime = canvas.NewText(fmt.Sprintf("摸鱼: %d 分钟", int(app.DistractingTime.Minutes())), nil)
+	productiveTime.TextSize, neutralTime.TextSize, distractingTime.TextSize = 14, 14, 14
+
+	productiveTime.Alignment = fyne.TextAlignLeading
+	neutralTime.Alignment = fyne.TextAlignCenter
+	distractingTime.Alignment = fyne.TextAlignTrailing
+	return productiveTime, neutralTime, distractingTime
+}
+
+// loadTodaySummary 从数据库读取今日的摸鱼次数、完成数和各分类时长
+func (app *Config) loadTodaySummary() {
+	s, err := app.DB.GetSummaryByDay(time.Now().Format("2006-01-02"))
+	if err != nil {
+		app.ErrorLog.Println(err)
+		return
+	}
+	app.FishCount = int(s.FishCount)
+	app.FinishCount = int(s.FinishCount)
+	app.ProductiveTime = time.Duration(s.ProductiveSeconds) * time.Second
+	app.NeutralTime = time.Duration(s.NeutralSeconds) * time.Second
+	app.DistractingTime = time.Duration(s.DistractingSeconds) * time.Second
+}
+
+// currentBalance 从积分流水中读取当前余额
+func (app *Config) currentBalance() int {
+	balance, err := app.DB.PointsBalance()
+	if err != nil {
+		app.ErrorLog.Println(err)
+		return app.PrizeCount
+	}
+	return balance
+}
+
 // refreshSum  刷新总览
 func (app *Config) refreshSum() {
 	app.InfoLog.Println("刷新总览")
 	// 重新获取总览 并刷新
 	fishCount, finishCount, prizeCount := app.getSum()
-	app.Summary.Objects = []fyne.CanvasObject{fishCount, finishCount, prizeCount}
+	productiveTime, neutralTime, distractingTime := app.getTimeSum()
+	app.Summary.Objects = []fyne.CanvasObject{fishCount, finishCount, prizeCount, productiveTime, neutralTime, distractingTime}
 	app.Summary.Refresh()
 }
 
@@ -74,6 +126,8 @@ func (app *Config) refreshTasksTable() {
 	app.InfoLog.Println("刷新任务列表")
 	app.Tasks = app.getTaskSlice()
 	app.TasksTable.Refresh()
+	app.CompletedTasks = app.getCompletedTaskSlice()
+	app.CompletedTasksTable.Refresh()
 }
 
 func (app *Config) refreshPrizesTable() {
@@ -81,17 +135,3 @@ func (app *Config) refreshPrizesTable() {
 	app.Prizes = app.getPrizeSlice()
 	app.PrizesTable.Refresh()
 }
-
-func (app *Config) imgTab() *fyne.Container {
-
-	var img *canvas.Image
-	img = canvas.NewImageFromFile("gold.png ")
-	img.SetMinSize(fyne.Size{Width: 770, Height: 410})
-
-	img.FillMode = canvas.ImageFillOriginal
-
-	charContainer := container.NewVBox(img)
-
-	return charContainer
-
-}
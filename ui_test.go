@@ -0,0 +1,38 @@
+package main
+
+import (
+	"NoFish/repository"
+	"testing"
+	"time"
+)
+
+func TestApp_getTaskSlice(t *testing.T) {
+	open, _ := testApp.DB.InsertTask(repository.Task{Name: "读书", DueDate: time.Now(), Points: 2, Priority: 1, EstimateMinutes: 90, SpentSeconds: 45 * 60})
+	done, _ := testApp.DB.InsertTask(repository.Task{Name: "写周报", DueDate: time.Now(), Points: 3, Priority: 2})
+	if _, err := testApp.DB.CompleteTask(done.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	tasks := testApp.getTaskSlice()
+	completed := testApp.getCompletedTaskSlice()
+
+	// 第一行是表头
+	if len(tasks) != 2 || tasks[1][1] != open.Name || tasks[1][4] != "高" {
+		t.Error("wrong open tasks:", tasks)
+	}
+	// 预估和实际用时
+	if tasks[1][6] != "1:30" || tasks[1][7] != "0:45" || tasks[1][8] != "0" {
+		t.Error("wrong estimate or actual time:", tasks[1])
+	}
+	if len(completed) != 2 || completed[1][1] != done.Name {
+		t.Error("wrong completed tasks:", completed)
+	}
+
+	testApp.loadTodaySummary()
+	if testApp.FinishCount != 1 {
+		t.Errorf("wrong finish count: expected 1, got %d", testApp.FinishCount)
+	}
+	if testApp.currentBalance() != 3 {
+		t.Errorf("wrong balance: expected 3, got %d", testApp.currentBalance())
+	}
+}